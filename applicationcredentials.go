@@ -37,6 +37,12 @@ func (s ApplicationCredential) Name() string {
 	return s.resource.Name
 }
 
+// InfraID returns the empty string: application credentials are only
+// tagged with the CI cluster name.
+func (s ApplicationCredential) InfraID() string {
+	return ""
+}
+
 func (s ApplicationCredential) ClusterID() string {
 	for _, tag := range strings.Split(s.resource.Description, " ") {
 		// https://github.com/openshift/release/pull/43348
@@ -47,6 +53,17 @@ func (s ApplicationCredential) ClusterID() string {
 	return ""
 }
 
+// Metadata returns the key=value pairs found in the description.
+func (s ApplicationCredential) Metadata() map[string]string {
+	metadata := make(map[string]string)
+	for _, field := range strings.Fields(s.resource.Description) {
+		if key, value, ok := strings.Cut(field, "="); ok {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
 func getUserID(ctx context.Context, client *gophercloud.ServiceClient) (string, error) {
 	var token struct {
 		User struct {
@@ -57,29 +74,38 @@ func getUserID(ctx context.Context, client *gophercloud.ServiceClient) (string,
 	return token.User.ID, err
 }
 
-func ListPerishableApplicationCredentials(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func ListApplicationCredentials(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
+	ch := make(chan Resource)
 	userID, err := getUserID(ctx, client)
 	if err != nil {
-		panic(err)
+		errs <- ListError{ResourceType: "appcreds", Err: err}
+		close(ch)
+		return ch
 	}
-	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
 		if err := applicationcredentials.List(client, userID, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := applicationcredentials.ExtractApplicationCredentials(page)
 			for i := range resources {
-				if !resources[i].ExpiresAt.IsZero() {
-					ch <- ApplicationCredential{
-						resource: &resources[i],
-						client:   client,
-						userID:   userID,
-					}
+				ch <- ApplicationCredential{
+					resource: &resources[i],
+					client:   client,
+					userID:   userID,
 				}
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "appcreds", Err: err}
 		}
 	}()
 	return ch
 }
+
+// IsPerishable selects the application credentials that expire. The others
+// are not meant to be pruned.
+func IsPerishable(resource Resource) bool {
+	if appCred, ok := resource.(ApplicationCredential); ok {
+		return !appCred.resource.ExpiresAt.IsZero()
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"gopkg.in/yaml.v2"
+)
+
+// cloudNames holds the clouds.yaml entries to prune. The empty string stands
+// for the cloud set in OS_CLOUD.
+var cloudNames = func() []string {
+	var names []string
+	for _, arg := range os.Args {
+		if arg == "--all-clouds" {
+			names, err := allCloudNames()
+			if err != nil {
+				panic(err)
+			}
+			return names
+		}
+		if value := strings.TrimPrefix(arg, "--cloud="); value != arg && value != "" {
+			names = append(names, strings.Split(value, ",")...)
+		}
+	}
+	if len(names) == 0 {
+		return []string{""}
+	}
+	return names
+}()
+
+// regionNames holds the regions to prune, or "all" for every region of the
+// Keystone catalog. When empty, only the region of the clouds.yaml entry is
+// pruned.
+var regionNames = func() []string {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--regions="); value != arg && value != "" {
+			return strings.Split(value, ",")
+		}
+	}
+	return nil
+}()
+
+// regionsOf returns the regions to prune in the cloud of the provider client.
+func regionsOf(ctx context.Context, providerClient *gophercloud.ProviderClient, identityClient *gophercloud.ServiceClient, eo gophercloud.EndpointOpts) ([]string, error) {
+	switch {
+	case len(regionNames) == 0:
+		return []string{eo.Region}, nil
+	case len(regionNames) == 1 && regionNames[0] == "all":
+	default:
+		return regionNames, nil
+	}
+
+	catalog, err := tokens.Get(ctx, identityClient, providerClient.Token()).ExtractServiceCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the service catalog: %w", err)
+	}
+	found := make(map[string]bool)
+	var regions []string
+	for _, entry := range catalog.Entries {
+		for _, endpoint := range entry.Endpoints {
+			region := endpoint.RegionID
+			if region == "" {
+				region = endpoint.Region
+			}
+			if region != "" && !found[region] {
+				found[region] = true
+				regions = append(regions, region)
+			}
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// allCloudNames returns the names of the entries of the clouds.yaml file
+// found in the same locations as clouds.Parse looks into.
+func allCloudNames() ([]string, error) {
+	locations := []string{os.Getenv("OS_CLIENT_CONFIG_FILE")}
+	if locations[0] == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the current working directory: %w", err)
+		}
+		userConfig, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the user config directory: %w", err)
+		}
+		locations = []string{path.Join(cwd, "clouds.yaml"), path.Join(userConfig, "openstack", "clouds.yaml"), path.Join("/etc", "openstack", "clouds.yaml")}
+	}
+
+	for _, location := range locations {
+		b, err := os.ReadFile(location)
+		if err != nil {
+			continue
+		}
+		var cloudsYAML clouds.Clouds
+		if err := yaml.Unmarshal(b, &cloudsYAML); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", location, err)
+		}
+		names := make([]string, 0, len(cloudsYAML.Clouds))
+		for name := range cloudsYAML.Clouds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	return nil, fmt.Errorf("clouds file not found. Search locations were: %v", locations)
+}
+
+// locatedResource is a resource of a report merged from several clouds or
+// regions.
+type locatedResource struct {
+	Resource
+	Cloud  string
+	Region string
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"time"
+)
+
+// clusterIDOf returns the ID of the OpenShift cluster the resource belongs
+// to, or the empty string.
+func clusterIDOf(r Resource) string {
+	if c, ok := r.(Clusterer); ok {
+		return c.ClusterID()
+	}
+	return ""
+}
+
+// clusterCreationTimes estimates the creation time of each cluster found in
+// resources: the creation time of its oldest server or network, or of its
+// oldest resource if it has neither.
+func clusterCreationTimes(resources []Resource) map[string]time.Time {
+	anchors := make(map[string]time.Time)
+	oldest := make(map[string]time.Time)
+	for _, r := range resources {
+		clusterID := clusterIDOf(r)
+		if clusterID == "" {
+			continue
+		}
+		if t, ok := oldest[clusterID]; !ok || r.CreatedAt().Before(t) {
+			oldest[clusterID] = r.CreatedAt()
+		}
+		if r.Type() == "server" || r.Type() == "network" {
+			if t, ok := anchors[clusterID]; !ok || r.CreatedAt().Before(t) {
+				anchors[clusterID] = r.CreatedAt()
+			}
+		}
+	}
+	for clusterID, t := range oldest {
+		if _, ok := anchors[clusterID]; !ok {
+			anchors[clusterID] = t
+		}
+	}
+	return anchors
+}
+
+// ClusterCreatedBefore judges resources by the creation time of the cluster
+// they belong to, as estimated from the given resources, so that all the
+// resources of a stale cluster are selected together. Resources that do not
+// belong to a cluster are judged by the fallback filter.
+func ClusterCreatedBefore(resources []Resource, t time.Time, fallback func(Resource) bool) func(Resource) bool {
+	clusters := clusterCreationTimes(resources)
+	return func(resource Resource) bool {
+		if created, ok := clusters[clusterIDOf(resource)]; ok {
+			return created.Before(t)
+		}
+		return fallback(resource)
+	}
+}
+
+func ClusterIDIs(ids ...string) func(Resource) bool {
+	return func(resource Resource) bool {
+		clusterID := clusterIDOf(resource)
+		for i := range ids {
+			if clusterID != "" && clusterID == ids[i] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// liveClusters returns the IDs of the clusters that still have servers or
+// networks.
+func liveClusters(resources []Resource) map[string]bool {
+	live := make(map[string]bool)
+	for _, r := range resources {
+		if r.Type() == "server" || r.Type() == "network" {
+			if clusterID := clusterIDOf(r); clusterID != "" {
+				live[clusterID] = true
+			}
+		}
+	}
+	return live
+}
+
+// IsOrphan selects the resources that belong to a cluster that has neither
+// servers nor networks left among the given resources. Resources that are
+// only tagged with the CI cluster name are never orphans: the name cannot
+// be matched against the infrastructure ID of live servers and networks.
+func IsOrphan(resources []Resource) func(Resource) bool {
+	live := liveClusters(resources)
+	return func(resource Resource) bool {
+		clusterID := clusterIDOf(resource)
+		if r, ok := resource.(InfraIDer); ok {
+			clusterID = r.InfraID()
+		}
+		return clusterID != "" && !live[clusterID]
+	}
+}
+
+// prunableAlong reports whether a resource found while deleting another
+// resource of the given cluster would be pruned on its own, and may thus be
+// deleted or modified along with it: it must belong to the same cluster, be
+// processed according to --include, --exclude and the configuration file,
+// be stale, and not be protected nor managed by OpenStack. In cluster and
+// destroy modes, belonging to the cluster being deleted makes it stale.
+func prunableAlong(r Resource, clusterID string) bool {
+	now := time.Now()
+	resourceType := resourceTypeNames[r.Type()]
+	isStale := command == "destroy" || byCluster || OlderThanTTL(now)(r)
+	return clusterID != "" && clusterIDOf(r) == clusterID &&
+		shouldProcessResource(resourceType) && configuration.Filter(resourceType)(r) && IsNotOpenStackManaged(r) &&
+		isStale && IsNotProtected(now)(r)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed config.yaml
+var defaultConfig []byte
+
+// Config is the content of the file passed with --config.
+type Config struct {
+	// Resources holds the rules of each resource type, keyed by the name
+	// of the type as given to --include.
+	Resources map[string]ResourceConfig `yaml:"resources"`
+
+	// Projects selects, with --all-projects, the projects whose resources
+	// are processed.
+	Projects *ProjectConfig `yaml:"projects"`
+}
+
+type ProjectConfig struct {
+	// Include, if set, restricts processing to the projects it matches.
+	Include *Rules `yaml:"include"`
+
+	// Exclude prevents processing the projects it matches.
+	Exclude *Rules `yaml:"exclude"`
+}
+
+type ResourceConfig struct {
+	// Include, if set, restricts processing to the resources it matches.
+	Include *Rules `yaml:"include"`
+
+	// Exclude prevents processing the resources it matches.
+	Exclude *Rules `yaml:"exclude"`
+
+	// TTL, if set, overrides --resource-ttl for this resource type.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Rules match a resource if any of their conditions matches.
+type Rules struct {
+	Names        []string `yaml:"names"`
+	NameContains []string `yaml:"name_contains"`
+	NamePatterns []string `yaml:"name_patterns"`
+	IDs          []string `yaml:"ids"`
+	Tags         []string `yaml:"tags"`
+
+	patterns []*regexp.Regexp
+}
+
+var configuration = func() Config {
+	content := defaultConfig
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--config="); value != arg {
+			var err error
+			content, err = os.ReadFile(value)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	config, err := parseConfig(content)
+	if err != nil {
+		panic(err)
+	}
+	return config
+}()
+
+func parseConfig(content []byte) (Config, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return config, fmt.Errorf("failed to parse the configuration: %w", err)
+	}
+
+	valid := make(map[string]bool)
+	for _, t := range strings.Split(resourceTypes, ",") {
+		valid[t] = true
+	}
+	for resourceType, resourceConfig := range config.Resources {
+		if !valid[resourceType] {
+			return config, fmt.Errorf("invalid resource type %q in the configuration, valid types are: %s", resourceType, resourceTypes)
+		}
+		for _, rules := range []*Rules{resourceConfig.Include, resourceConfig.Exclude} {
+			if err := rules.compile(); err != nil {
+				return config, fmt.Errorf("invalid name pattern for %s in the configuration: %w", resourceType, err)
+			}
+		}
+	}
+	if config.Projects != nil {
+		for _, rules := range []*Rules{config.Projects.Include, config.Projects.Exclude} {
+			if err := rules.compile(); err != nil {
+				return config, fmt.Errorf("invalid name pattern for projects in the configuration: %w", err)
+			}
+		}
+	}
+	return config, nil
+}
+
+// Filter returns a filter function accepting the resources of the given type
+// that match the include rules, if any, and none of the exclude rules.
+func (c Config) Filter(resourceType string) func(Resource) bool {
+	resourceConfig := c.Resources[resourceType]
+	return func(resource Resource) bool {
+		if resourceConfig.Include != nil && !resourceConfig.Include.Match(resource) {
+			return false
+		}
+		if resourceConfig.Exclude != nil && resourceConfig.Exclude.Match(resource) {
+			return false
+		}
+		return true
+	}
+}
+
+// compile parses the name patterns of the rules, if any.
+func (r *Rules) compile() error {
+	if r == nil {
+		return nil
+	}
+	for _, pattern := range r.NamePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return nil
+}
+
+// Match reports whether the rules match the given resource or project.
+func (r *Rules) Match(resource interface {
+	Identifier
+	Namer
+}) bool {
+	for _, name := range r.Names {
+		if resource.Name() == name {
+			return true
+		}
+	}
+	for _, substring := range r.NameContains {
+		if strings.Contains(resource.Name(), substring) {
+			return true
+		}
+	}
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(resource.Name()) {
+			return true
+		}
+	}
+	for _, id := range r.IDs {
+		if resource.ID() == id {
+			return true
+		}
+	}
+	if tagger, ok := resource.(Tagger); ok {
+		for _, have := range tagger.Tags() {
+			for _, tag := range r.Tags {
+				if have == tag {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, tc := range [...]struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name:    "default",
+			content: string(defaultConfig),
+		},
+		{
+			name: "valid",
+			content: `
+resources:
+  servers:
+    include:
+      name_patterns: ["^ci-"]
+    exclude:
+      names: [bastion]
+    ttl: 3h
+projects:
+  include:
+    tags: [ci]
+`,
+		},
+		{
+			name: "invalid resource type",
+			content: `
+resources:
+  instances:
+    exclude:
+      names: [bastion]
+`,
+			expectedError: `invalid resource type "instances"`,
+		},
+		{
+			name: "invalid name pattern",
+			content: `
+resources:
+  servers:
+    exclude:
+      name_patterns: ["("]
+`,
+			expectedError: "invalid name pattern for servers",
+		},
+		{
+			name: "invalid project name pattern",
+			content: `
+projects:
+  include:
+    name_patterns: ["["]
+`,
+			expectedError: "invalid name pattern for projects",
+		},
+		{
+			name: "unknown field",
+			content: `
+resources:
+  servers:
+    exclude:
+      name: bastion
+`,
+			expectedError: "failed to parse the configuration",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseConfig([]byte(tc.content))
+			switch {
+			case tc.expectedError == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.expectedError != "" && err == nil:
+				t.Errorf("expected error %q, got none", tc.expectedError)
+			case tc.expectedError != "" && !strings.Contains(err.Error(), tc.expectedError):
+				t.Errorf("expected error %q, got %q", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestConfigFilter(t *testing.T) {
+	config, err := parseConfig([]byte(`
+resources:
+  ports:
+    include:
+      name_contains: [ci-op-]
+      ids: [included-id]
+    exclude:
+      names: [ci-op-bastion]
+      name_patterns: ["-keep$"]
+      tags: [owner=infra]
+  networks:
+    exclude:
+      ids: [external]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range [...]struct {
+		name         string
+		resourceType string
+		resource     fakeResource
+		expected     bool
+	}{
+		{"included by name", "ports", fakeResource{id: "1", name: "ci-op-abc"}, true},
+		{"included by ID", "ports", fakeResource{id: "included-id", name: "other"}, true},
+		{"not included", "ports", fakeResource{id: "2", name: "other"}, false},
+		{"excluded by name", "ports", fakeResource{id: "3", name: "ci-op-bastion"}, false},
+		{"excluded by pattern", "ports", fakeResource{id: "4", name: "ci-op-abc-keep"}, false},
+		{"excluded by tag", "ports", fakeResource{id: "5", name: "ci-op-abc", tags: []string{"owner=infra"}}, false},
+		{"other tags", "ports", fakeResource{id: "6", name: "ci-op-abc", tags: []string{"owner=ci"}}, true},
+		{"exclude only", "networks", fakeResource{id: "7", typ: "network", name: "anything"}, true},
+		{"excluded by ID", "networks", fakeResource{id: "external", typ: "network"}, false},
+		{"no rules", "volumes", fakeResource{id: "8", typ: "volume"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := config.Filter(tc.resourceType)(tc.resource); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
@@ -17,6 +18,7 @@ type Container struct {
 	resourceName       string
 	client             *gophercloud.ServiceClient
 	clusterID          string
+	metadata           map[string]string
 	associatedResource Resource
 }
 
@@ -84,12 +86,16 @@ func (s Container) ClusterID() string {
 	return s.clusterID
 }
 
+func (s Container) Metadata() map[string]string {
+	return s.metadata
+}
+
 type ContainerParser struct {
 	containers.Container
 	Properties map[string]string `json:"properties"`
 }
 
-func ListContainers(ctx context.Context, client *gophercloud.ServiceClient, networks <-chan Resource) <-chan Resource {
+func ListContainers(ctx context.Context, client *gophercloud.ServiceClient, networks <-chan Resource, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	clusterNetworks := make(map[string]Resource)
 	for network := range networks {
@@ -114,6 +120,12 @@ func ListContainers(ctx context.Context, client *gophercloud.ServiceClient, netw
 					resourceName: containerName,
 					client:       client,
 					clusterID:    getResult.Header.Get("X-Container-Meta-Openshiftclusterid"),
+					metadata:     make(map[string]string),
+				}
+				for header := range getResult.Header {
+					if key := strings.TrimPrefix(header, "X-Container-Meta-"); key != header {
+						c.metadata[strings.ToLower(key)] = getResult.Header.Get(header)
+					}
 				}
 				if n, ok := clusterNetworks[c.ClusterID()]; ok {
 					c.associatedResource = n
@@ -125,7 +137,7 @@ func ListContainers(ctx context.Context, client *gophercloud.ServiceClient, netw
 			if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
 				log.Printf("Skipping containers deletion. User not authorized to perform the requested action")
 			} else {
-				panic(err)
+				errs <- ListError{ResourceType: "containers", Err: err}
 			}
 		}
 	}()
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Decision is the verdict of one filter on a resource.
+type Decision struct {
+	Filter   string `json:"filter"`
+	Accepted bool   `json:"accepted"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Explanations records the decisions taken on every listed resource. A nil
+// *Explanations records nothing.
+type Explanations struct {
+	mu        sync.Mutex
+	resources []Resource
+	decisions map[[2]string][]Decision
+}
+
+func NewExplanations() *Explanations {
+	return &Explanations{
+		decisions: make(map[[2]string][]Decision),
+	}
+}
+
+func (e *Explanations) Record(r Resource, filter string, accepted bool, detail string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := [2]string{r.Type(), r.ID()}
+	if _, ok := e.decisions[key]; !ok {
+		e.resources = append(e.resources, r)
+	}
+	e.decisions[key] = append(e.decisions[key], Decision{Filter: filter, Accepted: accepted, Detail: detail})
+}
+
+// Explained wraps a filter function so that its decisions are recorded under
+// the given filter name.
+func (e *Explanations) Explained(filter string, filterFunction func(Resource) bool) func(Resource) bool {
+	if e == nil {
+		return filterFunction
+	}
+	return func(resource Resource) bool {
+		accepted := filterFunction(resource)
+		e.Record(resource, filter, accepted, "")
+		return accepted
+	}
+}
+
+// Of returns the decisions taken on the resources of the given type whose ID
+// or name is idOrName.
+func (e *Explanations) Of(resourceType, idOrName string) *Explanations {
+	found := NewExplanations()
+	if e == nil {
+		return found
+	}
+	for _, r := range e.resources {
+		if resourceTypeNames[r.Type()] == resourceType && (r.ID() == idOrName || r.Name() == idOrName) {
+			found.resources = append(found.resources, r)
+			found.decisions[[2]string{r.Type(), r.ID()}] = e.decisions[[2]string{r.Type(), r.ID()}]
+		}
+	}
+	return found
+}
+
+func (e *Explanations) Len() int {
+	if e == nil {
+		return 0
+	}
+	return len(e.resources)
+}
+
+func (e *Explanations) MarshalJSON() ([]byte, error) {
+	type explanationPrinter struct {
+		resourcePrinter
+		Selected  bool       `json:"selected"`
+		Decisions []Decision `json:"decisions"`
+	}
+
+	printers := make([]explanationPrinter, len(e.resources))
+	for i, r := range e.resources {
+		decisions := e.decisions[[2]string{r.Type(), r.ID()}]
+		selected := true
+		for _, decision := range decisions {
+			selected = selected && decision.Accepted
+		}
+		printers[i] = explanationPrinter{
+			resourcePrinter: printResource(r),
+			Selected:        selected,
+			Decisions:       decisions,
+		}
+	}
+	return json.Marshal(printers)
+}
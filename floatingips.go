@@ -35,15 +35,28 @@ func (s FloatingIP) Name() string {
 	return s.resource.FloatingIP
 }
 
+func (s FloatingIP) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s FloatingIP) Tags() []string {
 	return s.resource.Tags
 }
 
-func (s FloatingIP) ClusterID() string {
+func (s FloatingIP) InfraID() string {
 	for _, tag := range s.resource.Tags {
 		if value := strings.TrimPrefix(tag, "openshiftClusterID="); value != tag {
 			return value
 		}
+	}
+	return ""
+}
+
+func (s FloatingIP) ClusterID() string {
+	if infraID := s.InfraID(); infraID != "" {
+		return infraID
+	}
+	for _, tag := range s.resource.Tags {
 		// https://github.com/openshift/release/pull/43063
 		if value := strings.TrimPrefix(tag, "PROW_CLUSTER_NAME="); value != tag {
 			return value
@@ -52,7 +65,15 @@ func (s FloatingIP) ClusterID() string {
 	return ""
 }
 
-func ListFloatingIPs(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s FloatingIP) References() []string {
+	return []string{s.resource.PortID, s.resource.RouterID}
+}
+
+func (s FloatingIP) AttachedTo() []string {
+	return []string{s.resource.PortID}
+}
+
+func ListFloatingIPs(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
@@ -67,7 +88,7 @@ func ListFloatingIPs(ctx context.Context, client *gophercloud.ServiceClient) <-c
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "floatingips", Err: err}
 		}
 	}()
 	return ch
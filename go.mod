@@ -5,9 +5,7 @@ go 1.22
 require (
 	github.com/gophercloud/gophercloud/v2 v2.0.0-rc.3
 	golang.org/x/term v0.21.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
-require (
-	golang.org/x/sys v0.21.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
-)
+require golang.org/x/sys v0.21.0 // indirect
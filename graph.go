@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// dependencyGraph orders the deletion of a set of resources so that every
+// resource is only deleted once the resources that depend on it are gone.
+type dependencyGraph struct {
+	nodes []Resource
+	index map[string]int
+
+	// next[i] holds the nodes that can only be deleted after node i.
+	next [][]int
+
+	// blockers[i] is the number of nodes that must be deleted before node i.
+	blockers []int
+}
+
+func newDependencyGraph(resources []Resource) *dependencyGraph {
+	g := &dependencyGraph{
+		nodes:    resources,
+		index:    make(map[string]int, len(resources)),
+		next:     make([][]int, len(resources)),
+		blockers: make([]int, len(resources)),
+	}
+	for i := range resources {
+		g.index[resources[i].ID()] = i
+	}
+
+	edges := make(map[[2]int]bool)
+	addEdge := func(before, after int) {
+		if before == after || edges[[2]int{before, after}] {
+			return
+		}
+		edges[[2]int{before, after}] = true
+		g.next[before] = append(g.next[before], after)
+		g.blockers[after]++
+	}
+
+	for i := range resources {
+		if referrer, ok := resources[i].(Referrer); ok {
+			for _, id := range referrer.References() {
+				if j, ok := g.index[id]; ok {
+					addEdge(i, j)
+				}
+			}
+		}
+		if dependent, ok := resources[i].(Dependent); ok {
+			for _, id := range dependent.DependsOn() {
+				if j, ok := g.index[id]; ok {
+					addEdge(j, i)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Delete deletes the resources leaves first, running independent deletions
+// concurrently within the limits of the pool. With --verify, a resource only
+// counts as deleted once it has disappeared. A resource is only attempted
+// once everything that depends on it has been deleted; if one of them fails,
+// the resource is reported as failed without being attempted.
+func (g *dependencyGraph) Delete(ctx context.Context, pool *workerPool, report *Report) {
+	type deletion struct {
+		node     int
+		attempts []deleteAttempt
+		err      error
+	}
+
+	blockers := make([]int, len(g.blockers))
+	copy(blockers, g.blockers)
+	done := make([]bool, len(g.nodes))
+
+	var ready []int
+	for i := range g.nodes {
+		if blockers[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	results := make(chan deletion)
+	var inflight int
+	for remaining := len(g.nodes); remaining > 0 || inflight > 0; {
+		for _, i := range ready {
+			if done[i] {
+				continue
+			}
+			done[i] = true
+			remaining--
+			inflight++
+			go func(i int) {
+				var statusBefore string
+				if verify {
+					statusBefore = statusOf(ctx, g.nodes[i])
+				}
+				attempts, err := deleteWithRetry(ctx, g.nodes[i], pool, maxAttempts)
+				if err == nil && verify {
+					err = waitUntilGone(ctx, g.nodes[i], statusBefore, verifyTimeout)
+					if err != nil {
+						log.Printf("%s %q was not deleted: %v\n", g.nodes[i].Type(), g.nodes[i].ID(), err)
+					}
+				}
+				results <- deletion{node: i, attempts: attempts, err: err}
+			}(i)
+		}
+		ready = ready[:0]
+
+		if inflight == 0 {
+			// Only dependency cycles are left. Break them by attempting
+			// the first pending resource anyway.
+			for i := range g.nodes {
+				if !done[i] {
+					log.Printf("Dependency cycle detected, attempting %s %q anyway\n", g.nodes[i].Type(), g.nodes[i].ID())
+					ready = append(ready, i)
+					break
+				}
+			}
+			continue
+		}
+
+		res := <-results
+		inflight--
+		report.AddDeleteAttempts(g.nodes[res.node], res.attempts)
+		if res.err != nil {
+			var wentToError errWentToError
+			switch {
+			case errors.Is(res.err, errStillDeleting):
+				report.AddStillDeleting(g.nodes[res.node])
+			case errors.As(res.err, &wentToError):
+				report.AddWentToError(g.nodes[res.node])
+			default:
+				report.AddFailedToDelete(g.nodes[res.node])
+			}
+			remaining -= g.skipDependents(res.node, done, report)
+			continue
+		}
+		report.AddDeleted(g.nodes[res.node])
+		for _, j := range g.next[res.node] {
+			blockers[j]--
+			if blockers[j] == 0 && !done[j] {
+				ready = append(ready, j)
+			}
+		}
+	}
+}
+
+// skipDependents marks every pending node that transitively depends on node
+// i as failed, and returns the number of nodes it marked.
+func (g *dependencyGraph) skipDependents(i int, done []bool, report *Report) int {
+	var skipped int
+	for _, j := range g.next[i] {
+		if done[j] {
+			continue
+		}
+		done[j] = true
+		skipped++
+		log.Printf("not deleting %s %q because %s %q could not be deleted\n", g.nodes[j].Type(), g.nodes[j].ID(), g.nodes[i].Type(), g.nodes[i].ID())
+		report.AddFailedToDelete(g.nodes[j])
+		skipped += g.skipDependents(j, done, report)
+	}
+	return skipped
+}
+
+func deleteResource(ctx context.Context, r Resource) error {
+	log.Printf("Deleting %s %q (created at %s)...\n", r.Type(), r.ID(), r.CreatedAt().Format(time.RFC3339))
+	if err := r.Delete(ctx); err != nil {
+		log.Printf("error deleting %s %q: %v\n", r.Type(), r.ID(), err)
+		return err
+	}
+	log.Printf("deleted %s %q\n", r.Type(), r.ID())
+	return nil
+}
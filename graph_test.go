@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDependencyGraphDelete(t *testing.T) {
+	type testCase struct {
+		name      string
+		resources []fakeResource
+
+		// before holds pairs of IDs that must be deleted in this order.
+		before         [][2]string
+		deleted        []string
+		failedToDelete []string
+		notAttempted   []string
+	}
+
+	errFailed := errors.New("failed")
+	for _, tc := range [...]testCase{
+		{
+			name: "references are deleted after the referrer",
+			resources: []fakeResource{
+				{id: "network", typ: "network"},
+				{id: "port", references: []string{"network"}},
+				{id: "server", typ: "server", references: []string{"port"}},
+			},
+			before:  [][2]string{{"server", "port"}, {"port", "network"}},
+			deleted: []string{"network", "port", "server"},
+		},
+		{
+			name: "dependents are deleted after what they depend on",
+			resources: []fakeResource{
+				{id: "group", typ: "server group", dependsOn: []string{"server"}},
+				{id: "server", typ: "server"},
+			},
+			before:  [][2]string{{"server", "group"}},
+			deleted: []string{"group", "server"},
+		},
+		{
+			name: "references to resources that are not deleted are ignored",
+			resources: []fakeResource{
+				{id: "port", references: []string{"kept network"}},
+			},
+			deleted: []string{"port"},
+		},
+		{
+			name: "cycles are broken",
+			resources: []fakeResource{
+				{id: "a", references: []string{"b"}},
+				{id: "b", references: []string{"a"}},
+				{id: "c", references: []string{"a"}},
+			},
+			before:  [][2]string{{"c", "a"}},
+			deleted: []string{"a", "b", "c"},
+		},
+		{
+			name: "resources needed by a failed resource are not attempted",
+			resources: []fakeResource{
+				{id: "network", typ: "network"},
+				{id: "subnet", typ: "subnet", references: []string{"network"}},
+				{id: "port", references: []string{"subnet"}},
+				{id: "server", typ: "server", references: []string{"port"}, deleteErr: errFailed},
+				{id: "other", typ: "volume"},
+			},
+			deleted:        []string{"other"},
+			failedToDelete: []string{"network", "port", "server", "subnet"},
+			notAttempted:   []string{"network", "port", "subnet"},
+		},
+		{
+			name: "dependents of a failed resource are not attempted",
+			resources: []fakeResource{
+				{id: "group", typ: "server group", dependsOn: []string{"server"}},
+				{id: "server", typ: "server", deleteErr: errFailed},
+			},
+			failedToDelete: []string{"group", "server"},
+			notAttempted:   []string{"group"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var log deletionLog
+			resources := make([]Resource, len(tc.resources))
+			for i := range tc.resources {
+				tc.resources[i].log = &log
+				resources[i] = tc.resources[i]
+			}
+
+			var report Report
+			newDependencyGraph(resources).Delete(context.Background(), newWorkerPool(4, nil), &report)
+
+			if got := idsOf(report.Deleted); !reflect.DeepEqual(got, nonNil(tc.deleted)) {
+				t.Errorf("deleted: expected %v, got %v", tc.deleted, got)
+			}
+			if got := idsOf(report.FailedToDelete); !reflect.DeepEqual(got, nonNil(tc.failedToDelete)) {
+				t.Errorf("failed to delete: expected %v, got %v", tc.failedToDelete, got)
+			}
+
+			position := make(map[string]int, len(log.ids))
+			for i, id := range log.ids {
+				if _, ok := position[id]; ok {
+					t.Errorf("%q was attempted more than once", id)
+				}
+				position[id] = i
+			}
+			for _, pair := range tc.before {
+				if position[pair[0]] > position[pair[1]] {
+					t.Errorf("expected %q to be deleted before %q, got order %v", pair[0], pair[1], log.ids)
+				}
+			}
+			for _, id := range tc.notAttempted {
+				if _, ok := position[id]; ok {
+					t.Errorf("expected %q not to be attempted", id)
+				}
+			}
+		})
+	}
+}
+
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
@@ -35,6 +35,10 @@ func (s Image) Name() string {
 	return s.resource.Name
 }
 
+func (s Image) ProjectID() string {
+	return s.resource.Owner
+}
+
 func (s Image) Tags() []string {
 	return s.resource.Tags
 }
@@ -49,11 +53,16 @@ func (s Image) ClusterID() string {
 	return ""
 }
 
-func ListImages(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func ListImages(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
-		if err := images.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		var opts images.ListOpts
+		if allProjects {
+			// Include the private images of the other projects
+			opts.Visibility = "all"
+		}
+		if err := images.List(client, opts).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := images.ExtractImages(page)
 			for i := range resources {
 				ch <- Image{
@@ -63,7 +72,7 @@ func ListImages(ctx context.Context, client *gophercloud.ServiceClient) <-chan R
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "images", Err: err}
 		}
 	}()
 	return ch
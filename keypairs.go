@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
@@ -10,17 +14,35 @@ import (
 	"github.com/gophercloud/gophercloud/v2/pagination"
 )
 
+// keyPairUser is the ID of the user whose key pairs are processed. Key pairs
+// belong to a user rather than to a project: by default, those of the
+// authenticated user are processed. Listing the key pairs of another user
+// requires admin credentials.
+var keyPairUser = func() string {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--keypair-user="); value != arg {
+			return value
+		}
+	}
+	return ""
+}()
+
+// keyPairGetConcurrency bounds the number of key pairs fetched at once to get
+// their creation time, which Nova only returns when showing a single key pair.
+const keyPairGetConcurrency = 16
+
 type KeyPair struct {
-	resource *KeyPairParser
-	client   *gophercloud.ServiceClient
+	resource  *keypairs.KeyPair
+	client    *gophercloud.ServiceClient
+	createdAt time.Time
 }
 
 func (s KeyPair) CreatedAt() time.Time {
-	return s.resource.UpdatedAt
+	return s.createdAt
 }
 
 func (s KeyPair) Delete(ctx context.Context) error {
-	return keypairs.Delete(ctx, s.client, s.ID(), keypairs.DeleteOpts{}).ExtractErr()
+	return keypairs.Delete(ctx, s.client, s.ID(), keypairs.DeleteOpts{UserID: keyPairUser}).ExtractErr()
 }
 
 func (s KeyPair) Type() string {
@@ -35,37 +57,58 @@ func (s KeyPair) Name() string {
 	return s.resource.Name
 }
 
-type KeyPairParser struct {
-	keypairs.KeyPair
-	UpdatedAt time.Time `json:"created_at"`
-}
-
-func ListKeyPairs(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+// ListKeyPairs lists the key pairs of the user set with --keypair-user, and
+// fetches each of them concurrently to get its creation time. Key pairs that
+// cannot be fetched are reported as listing errors, and not processed.
+func ListKeyPairs(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
-		if err := keypairs.List(client, nil).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		sem := make(chan struct{}, keyPairGetConcurrency)
+		if err := keypairs.List(client, keypairs.ListOpts{UserID: keyPairUser}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
 			keypairPage, err := keypairs.ExtractKeyPairs(page)
 			if err != nil {
 				return true, err
 			}
 			for i := range keypairPage {
-				var k struct {
-					KeyPairParser `json:"keypair"`
-				}
-				if err := keypairs.Get(ctx, client, keypairPage[i].Name, nil).ExtractInto(&k); err != nil {
-					return true, err
-				}
-				log.Println(k)
-				ch <- KeyPair{
-					resource: &k.KeyPairParser,
-					client:   client,
-				}
+				wg.Add(1)
+				sem <- struct{}{}
+				go func(keyPair *keypairs.KeyPair) {
+					defer wg.Done()
+					defer func() { <-sem }()
+					createdAt, err := keyPairCreatedAt(ctx, client, keyPair.Name)
+					switch {
+					case gophercloud.ResponseCodeIs(err, http.StatusNotFound):
+						// Deleted since it was listed
+					case err != nil:
+						errs <- ListError{ResourceType: "keypairs", Err: fmt.Errorf("failed to get key pair %q: %w", keyPair.Name, err)}
+					default:
+						ch <- KeyPair{
+							resource:  keyPair,
+							client:    client,
+							createdAt: createdAt,
+						}
+					}
+				}(&keypairPage[i])
 			}
-			return true, err
+			return true, nil
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "keypairs", Err: err}
 		}
 	}()
 	return ch
 }
+
+func keyPairCreatedAt(ctx context.Context, client *gophercloud.ServiceClient, name string) (time.Time, error) {
+	var k struct {
+		KeyPair struct {
+			CreatedAt gophercloud.JSONRFC3339MilliNoZ `json:"created_at"`
+		} `json:"keypair"`
+	}
+	if err := keypairs.Get(ctx, client, name, keypairs.GetOpts{UserID: keyPairUser}).ExtractInto(&k); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time(k.KeyPair.CreatedAt), nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/config"
+	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+)
+
+// authenticate returns a provider client for the given cloud, and the
+// endpoint options of its clouds.yaml entry. The empty cloud name stands for
+// the cloud set in OS_CLOUD.
+func authenticate(ctx context.Context, cloud string) (*gophercloud.ProviderClient, gophercloud.EndpointOpts, error) {
+	var parseOpts []clouds.ParseOption
+	if cloud != "" {
+		parseOpts = append(parseOpts, clouds.WithCloudName(cloud))
+	}
+	ao, eo, tlsConfig, err := clouds.Parse(parseOpts...)
+	if err != nil {
+		return nil, eo, fmt.Errorf("failed to parse clouds.yaml: %w", err)
+	}
+	providerClient, err := config.NewProviderClient(ctx, ao, config.WithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, eo, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	return providerClient, eo, nil
+}
+
+// newRegionalClient returns a client for the service, or nil if the service
+// has no endpoint in the region of the endpoint options, in which case the
+// resource types it serves are skipped in that region. Other errors are
+// returned, and added to the report as a listing error of each resource type
+// the service serves.
+func newRegionalClient(service string, newClient func(*gophercloud.ProviderClient, gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error), providerClient *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
+	client, err := newClient(providerClient, eo)
+	if err != nil {
+		var gerr *gophercloud.ErrEndpointNotFound
+		if !errors.As(err, &gerr) {
+			return nil, fmt.Errorf("failed to create the %s client: %w", service, err)
+		}
+		log.Printf("Skipping %s resources because its endpoint was not found in region %q\n", service, eo.Region)
+		return nil, nil
+	}
+	return client, nil
+}
+
+// listResources lists the resources of the region given in the endpoint
+// options, of the types accepted by shouldProcess, that pass the
+// configuration filters. Application credentials and projects are listed
+// with identityClient, which belongs to the cloud rather than to the region.
+// Listing errors are added to the report, as well as the decisions of the
+// filters if the report has Explanations.
+func listResources(ctx context.Context, providerClient *gophercloud.ProviderClient, identityClient *gophercloud.ServiceClient, eo gophercloud.EndpointOpts, shouldProcess func(resourceType string) bool, report *Report) []Resource {
+	resources := make(chan Resource)
+	errs := make(chan error)
+
+	newClient := func(service string, newServiceClient func(*gophercloud.ProviderClient, gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error)) *gophercloud.ServiceClient {
+		client, err := newRegionalClient(service, newServiceClient, providerClient, eo)
+		if err != nil {
+			log.Println(err)
+			var resourceTypes []string
+			for typ, s := range resourceServices {
+				if s == service && shouldProcess(resourceTypeNames[typ]) {
+					resourceTypes = append(resourceTypes, resourceTypeNames[typ])
+				}
+			}
+			sort.Strings(resourceTypes)
+			for _, resourceType := range resourceTypes {
+				report.AddError(ListError{ResourceType: resourceType, Err: err})
+			}
+		}
+		return client
+	}
+	loadbalancerClient := newClient("octavia", openstack.NewLoadBalancerV2)
+	computeClient := newClient("nova", openstack.NewComputeV2)
+	if computeClient != nil {
+		// Required for server tags
+		computeClient.Microversion = "2.26"
+	}
+	networkClient := newClient("neutron", openstack.NewNetworkV2)
+	volumeClient := newClient("cinder", openstack.NewBlockStorageV3)
+	imageClient := newClient("glance", openstack.NewImageV2)
+	containerClient := newClient("swift", openstack.NewContainerV1)
+	shareClient := newClient("manila", openstack.NewSharedFileSystemV2)
+
+	go func() {
+		defer close(resources)
+		defer close(errs)
+
+		inProjects := func(Resource) bool { return true }
+		if allProjects {
+			inProjects = report.Explanations.Explained("project", InSelectedProjects(ctx, identityClient, *configuration.Projects, errs))
+		}
+		configured := func(resourceType string) func(Resource) bool {
+			configurationFilter := report.Explanations.Explained("configuration", configuration.Filter(resourceType))
+			return func(resource Resource) bool {
+				return inProjects(resource) && configurationFilter(resource)
+			}
+		}
+
+		if networkClient != nil && shouldProcess("floatingips") {
+			for res := range Filter(ListFloatingIPs(ctx, networkClient, errs), configured("floatingips")) {
+				resources <- res
+			}
+		}
+
+		if loadbalancerClient != nil && shouldProcess("loadbalancers") {
+			for res := range Filter(ListLoadBalancers(ctx, loadbalancerClient, errs), configured("loadbalancers")) {
+				resources <- res
+			}
+		}
+
+		// Servers are listed once, for themselves and for the server
+		// groups they are members of.
+		var servers []Resource
+		if computeClient != nil && (shouldProcess("servers") || shouldProcess("servergroups")) {
+			for res := range ListServers(ctx, computeClient, errs) {
+				servers = append(servers, res)
+			}
+		}
+
+		if computeClient != nil && shouldProcess("servers") {
+			isConfigured := configured("servers")
+			for _, res := range servers {
+				if isConfigured(res) {
+					resources <- res
+				}
+			}
+		}
+
+		if computeClient != nil && networkClient != nil && shouldProcess("servergroups") {
+			for res := range Filter(ListServerGroups(ctx, computeClient, servers, ListNetworks(ctx, networkClient, errs), errs), configured("servergroups")) {
+				resources <- res
+			}
+		}
+
+		if computeClient != nil && shouldProcess("keypairs") {
+			for res := range Filter(ListKeyPairs(ctx, computeClient, errs), configured("keypairs")) {
+				resources <- res
+			}
+		}
+
+		if networkClient != nil && shouldProcess("routers") {
+			for res := range Filter(ListRouters(ctx, networkClient, errs), configured("routers")) {
+				resources <- res
+			}
+		}
+
+		if networkClient != nil && shouldProcess("trunks") {
+			for res := range Filter(ListTrunks(ctx, networkClient, errs), configured("trunks")) {
+				resources <- res
+			}
+		}
+
+		if networkClient != nil && shouldProcess("ports") {
+			for res := range Filter(ListPorts(ctx, networkClient, errs), report.Explanations.Explained("not managed by OpenStack", IsNotOpenStackManaged), configured("ports")) {
+				resources <- res
+			}
+		}
+
+		if networkClient != nil && shouldProcess("subnets") {
+			// Without the authenticated project, only the subnets of
+			// clusters are processed.
+			projectID, err := getProjectID(ctx, identityClient)
+			if err != nil {
+				errs <- ListError{ResourceType: "subnets", Err: fmt.Errorf("failed to get the authenticated project: %w", err)}
+			}
+			for res := range Filter(ListSubnets(ctx, networkClient, errs), report.Explanations.Explained("owned", IsOwnedSubnet(projectID)), configured("subnets")) {
+				resources <- res
+			}
+		}
+
+		if networkClient != nil && shouldProcess("networks") {
+			for res := range Filter(ListNetworks(ctx, networkClient, errs), configured("networks")) {
+				resources <- res
+			}
+		}
+
+		if volumeClient != nil && shouldProcess("volumesnapshots") {
+			for res := range Filter(ListVolumeSnapshots(ctx, volumeClient, errs), configured("volumesnapshots")) {
+				resources <- res
+			}
+		}
+
+		if volumeClient != nil && shouldProcess("volumes") {
+			for res := range Filter(ListVolumes(ctx, volumeClient, computeClient, errs), configured("volumes")) {
+				resources <- res
+			}
+		}
+
+		if networkClient != nil && shouldProcess("securitygroups") {
+			for res := range Filter(ListSecurityGroups(ctx, networkClient, errs), configured("securitygroups")) {
+				resources <- res
+			}
+		}
+
+		if shareClient != nil && shouldProcess("shares") {
+			for res := range Filter(ListShares(ctx, shareClient, errs), configured("shares")) {
+				resources <- res
+			}
+		}
+
+		if shouldProcess("appcreds") {
+			for res := range Filter(ListApplicationCredentials(ctx, identityClient, errs), report.Explanations.Explained("expiring", IsPerishable), configured("appcreds")) {
+				resources <- res
+			}
+		}
+
+		if containerClient != nil && networkClient != nil && shouldProcess("containers") {
+			for res := range Filter(ListContainers(ctx, containerClient, ListNetworks(ctx, networkClient, errs), errs), configured("containers")) {
+				resources <- res
+			}
+		}
+
+		if imageClient != nil && shouldProcess("images") {
+			for res := range Filter(ListImages(ctx, imageClient, errs), configured("images")) {
+				resources <- res
+			}
+		}
+	}()
+
+	listingDone := make(chan struct{})
+	go func() {
+		defer close(listingDone)
+		for err := range errs {
+			log.Println(err)
+			report.AddError(err)
+		}
+	}()
+	var listed []Resource
+	for res := range resources {
+		listed = append(listed, res)
+	}
+	<-listingDone
+
+	return listed
+}
@@ -22,6 +22,14 @@ func (s LoadBalancer) Delete(ctx context.Context) error {
 	return loadbalancers.Delete(ctx, s.client, s.resource.ID, loadbalancers.DeleteOpts{Cascade: true}).ExtractErr()
 }
 
+func (s LoadBalancer) Status(ctx context.Context) (string, error) {
+	lb, err := loadbalancers.Get(ctx, s.client, s.resource.ID).Extract()
+	if err != nil {
+		return "", err
+	}
+	return lb.ProvisioningStatus, nil
+}
+
 func (s LoadBalancer) Type() string {
 	return "load balancer"
 }
@@ -34,11 +42,19 @@ func (s LoadBalancer) Name() string {
 	return s.resource.Name
 }
 
+func (s LoadBalancer) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s LoadBalancer) Tags() []string {
 	return s.resource.Tags
 }
 
-func ListLoadBalancers(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s LoadBalancer) References() []string {
+	return []string{s.resource.VipPortID, s.resource.VipNetworkID}
+}
+
+func ListLoadBalancers(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
@@ -52,7 +68,7 @@ func ListLoadBalancers(ctx context.Context, client *gophercloud.ServiceClient) <
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "loadbalancers", Err: err}
 		}
 	}()
 	return ch
@@ -7,38 +7,123 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"golang.org/x/term"
-
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack"
-	"github.com/gophercloud/gophercloud/v2/openstack/config"
-	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+	"golang.org/x/term"
 )
 
 const (
 	helpString = `Prune stale resources from cloud
 
 Usage: prune [OPTION]...
+       prune destroy --cluster-id=<id>[,<id>...] [OPTION]...
+       prune plan --out=<file> [OPTION]...
+       prune apply <file> [OPTION]...
+       prune explain <type> <id or name> [OPTION]...
+
+Commands:
+  destroy               Prune every resource of the given clusters regardless
+                        of its age
+  plan                  List the resources to prune, like a dry run, and write
+                        them to a plan file
+  apply                 Delete the resources of a plan file, except those that
+                        changed or got protected since the plan was written
+  explain               Show why a resource is or is not pruned
 
 Options:
+  --cluster-id=<ids>    Comma-separated list of the IDs of the clusters to
+                        destroy. Can be repeated
+  --out=<file>          File to write the plan to
   --resource-ttl=<ttl>  Minimum age of resources to prune. ttl is parsed
                         as a Go duration (e.g. "1h", "30m12s")
+  --type-ttl=<type>=<ttl>[,<type>=<ttl>...]
+                        Minimum age of resources to prune for the given
+                        resource types, overriding --resource-ttl and the
+                        configuration file
   --slack-hook=<hook>   Slack hook. If provided, updates will be posted to the
                         relevant Slack channel, otherwise they are dumped to
                         stdout
   --no-dry-run          Delete resources
+  --protection-warning=<duration>
+                        Report the time-bounded protections expiring within
+                        the given duration (default: 24h)
+  --by-cluster          Judge the age of the resources that belong to an
+                        OpenShift cluster by the age of the cluster, as given
+                        by its oldest server or network, and prune all the
+                        resources of a stale cluster together
+  --orphans             Also prune the resources of clusters that have neither
+                        servers nor networks left, once they are older than
+                        the orphan TTL
+  --orphan-ttl=<ttl>    Minimum age of orphan resources to prune (default: 1h)
+  --concurrency=<n>     Maximum number of concurrent deletions (default: 8)
+  --service-concurrency=<service>=<n>[,<service>=<n>...]
+                        Maximum number of concurrent deletions against a
+                        given service. Valid services are: nova, neutron,
+                        cinder, octavia, swift, manila, glance, keystone
+  --max-attempts=<n>    Maximum number of attempts to delete a resource when
+                        the API returns a transient error (default: 5)
+  --verify              After deleting, wait for each resource to disappear
+                        and report those still deleting or in error
+  --verify-timeout=<timeout>
+                        How long to wait for each deleted resource to
+                        disappear. Defaults to a per-type timeout
+  --config=<file>       YAML file declaring which resources of each type to
+                        process. Replaces the default configuration, which
+                        is shipped as config.yaml
+  --explain             Add to the report the decision of every filter on
+                        every listed resource
+  --cloud=<clouds>      Comma-separated list of the clouds.yaml entries to
+                        prune concurrently, producing a single report.
+                        Defaults to the cloud set in OS_CLOUD
+  --all-clouds          Prune every cloud of clouds.yaml
+  --regions=<regions>   Comma-separated list of the regions to prune
+                        concurrently, or "all" for every region of the
+                        Keystone catalog. Defaults to the region of the
+                        clouds.yaml entry
+  --all-projects        List the resources of every project, which requires
+                        admin credentials. The projects to process must be
+                        included in the configuration file
+  --keypair-user=<id>   ID of the user whose key pairs to process, which
+                        requires admin credentials. Defaults to the
+                        authenticated user
   --include=<types>     Comma-separated list of resource types to include
   --exclude=<types>     Comma-separated list of resource types to exclude
   --help                Show this help message and exit
 
-Available resource types: ` + resourceTypes
+Available resource types: ` + resourceTypes + `
+
+Exit status is 0 on success, and 3 if some clouds could not be reached or some
+resource types could not be listed. The others are processed regardless.
+`
 
-	resourceTypes = `floatingips,loadbalancers,servers,routers,trunks,ports,networks,volumesnapshots,volumes,securitygroups,shares,appcreds,containers,images`
+	resourceTypes = `floatingips,loadbalancers,servers,servergroups,keypairs,routers,trunks,ports,subnets,networks,volumesnapshots,volumes,securitygroups,shares,appcreds,containers,images`
+
+	// exitListingFailed is the exit status when some clouds could not be
+	// reached, or some resource types could not be listed.
+	exitListingFailed = 3
 )
 
+var command = func() string {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		return os.Args[1]
+	}
+	return ""
+}()
+
+var planOut = func() string {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--out="); value != arg {
+			return value
+		}
+	}
+	return ""
+}()
+
 var showHelp = func() bool {
 	for _, arg := range os.Args {
 		if arg == "--help" || arg == "-h" {
@@ -70,6 +155,57 @@ var dryRun = func() bool {
 	return true
 }()
 
+var maxAttempts = func() int {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--max-attempts="); value != arg {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				panic(err)
+			}
+			if n < 1 {
+				panic(fmt.Sprintf("invalid max attempts %d: must be at least 1", n))
+			}
+			return n
+		}
+	}
+	return 5
+}()
+
+var verify = func() bool {
+	for _, arg := range os.Args {
+		if arg == "--verify" {
+			return true
+		}
+	}
+	return false
+}()
+
+var verifyTimeout = func() time.Duration {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--verify-timeout="); value != arg {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				panic(err)
+			}
+			return d
+		}
+	}
+	return 0
+}()
+
+var protectionWarning = func() time.Duration {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--protection-warning="); value != arg {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				panic(err)
+			}
+			return d
+		}
+	}
+	return 24 * time.Hour
+}()
+
 var slackHook = func() string {
 	for _, arg := range os.Args {
 		if value := strings.TrimPrefix(arg, "--slack-hook="); value != arg {
@@ -79,6 +215,98 @@ var slackHook = func() string {
 	return ""
 }()
 
+var clusterIDs = func() []string {
+	var ids []string
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--cluster-id="); value != arg && value != "" {
+			ids = append(ids, strings.Split(value, ",")...)
+		}
+	}
+	return ids
+}()
+
+var byCluster = func() bool {
+	for _, arg := range os.Args {
+		if arg == "--by-cluster" {
+			return true
+		}
+	}
+	return false
+}()
+
+var orphans = func() bool {
+	for _, arg := range os.Args {
+		if arg == "--orphans" {
+			return true
+		}
+	}
+	return false
+}()
+
+var orphanTTL = func() time.Duration {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--orphan-ttl="); value != arg {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				panic(err)
+			}
+			return d
+		}
+	}
+	return time.Hour
+}()
+
+var explain = func() bool {
+	for _, arg := range os.Args {
+		if arg == "--explain" {
+			return true
+		}
+	}
+	return false
+}()
+
+var concurrency = func() int {
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--concurrency="); value != arg {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				panic(err)
+			}
+			if n < 1 {
+				panic(fmt.Sprintf("invalid concurrency %d: must be at least 1", n))
+			}
+			return n
+		}
+	}
+	return 8
+}()
+
+var serviceConcurrency = func() map[string]int {
+	limits := make(map[string]int)
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--service-concurrency="); value != arg {
+			for _, limit := range strings.Split(value, ",") {
+				service, n, ok := strings.Cut(limit, "=")
+				if !ok {
+					panic(fmt.Sprintf("invalid service concurrency %q: expected <service>=<n>", limit))
+				}
+				if !isService(service) {
+					panic(fmt.Sprintf("invalid service %q in service concurrency", service))
+				}
+				limit, err := strconv.Atoi(n)
+				if err != nil {
+					panic(err)
+				}
+				if limit < 1 {
+					panic(fmt.Sprintf("invalid concurrency %d for service %q: must be at least 1", limit, service))
+				}
+				limits[service] = limit
+			}
+		}
+	}
+	return limits
+}()
+
 var (
 	includeResources = func() []string {
 		for _, arg := range os.Args {
@@ -121,6 +349,45 @@ type Namer interface{ Name() string }
 type Clusterer interface{ ClusterID() string }
 type Tagger interface{ Tags() []string }
 
+// InfraIDer is implemented by resources whose ClusterID may be the CI
+// cluster name (PROW_CLUSTER_NAME) rather than the infrastructure ID that
+// servers and networks are tagged with. InfraID returns the infrastructure
+// ID only, or the empty string.
+type InfraIDer interface{ InfraID() string }
+
+// Projecter is implemented by resources that belong to a project.
+type Projecter interface{ ProjectID() string }
+
+// Metadater is implemented by resources that carry key-value metadata rather
+// than tags.
+type Metadater interface{ Metadata() map[string]string }
+
+// Statuser is implemented by resources that are deleted asynchronously.
+// Status returns an error with a 404 response code once the resource is gone.
+type Statuser interface {
+	Status(context.Context) (string, error)
+}
+
+// Detacher is implemented by resources that must be detached before they
+// are deleted. Detach starts detaching the resource, and WaitUntilDetached
+// waits for the detachment to complete.
+type Detacher interface {
+	Detach(context.Context) error
+	WaitUntilDetached(context.Context) error
+}
+
+// Referrer is implemented by resources that use other resources. The
+// referenced resources are only deleted once the Referrer is gone.
+type Referrer interface{ References() []string }
+
+// Dependent is implemented by resources that are attached to other resources.
+// A Dependent is only deleted once the resources it depends on are gone.
+type Dependent interface{ DependsOn() []string }
+
+// Attacher is implemented by resources that provide connectivity to other
+// resources. Attachers are protected along with the resources they attach to.
+type Attacher interface{ AttachedTo() []string }
+
 func validateResourceTypes(include, exclude []string) error {
 	valid := strings.Split(resourceTypes, ",")
 	validMap := make(map[string]bool)
@@ -185,8 +452,22 @@ func shouldProcessResource(resourceType string) bool {
 	return true
 }
 
-// TODO:  server groups, keypairs
-// TODO: volume admin setting
+// canDetectOrphans reports whether both servers and networks were listed
+// successfully, as well as the projects selecting them if any, so that the
+// clusters they belong to are known to be live.
+func canDetectOrphans(errs []error) bool {
+	if !shouldProcessResource("servers") || !shouldProcessResource("networks") {
+		return false
+	}
+	for _, err := range errs {
+		var listErr ListError
+		if errors.As(err, &listErr) && (listErr.ResourceType == "servers" || listErr.ResourceType == "networks" || listErr.ResourceType == "projects") {
+			return false
+		}
+	}
+	return true
+}
+
 func main() {
 	ctx := context.Background()
 	if showHelp {
@@ -197,190 +478,248 @@ func main() {
 	if err := validateResourceTypes(includeResources, excludeResources); err != nil {
 		log.Fatal(err)
 	}
+	// Listing every project with admin credentials would otherwise prune
+	// resources that have nothing to do with CI.
+	if allProjects && (configuration.Projects == nil || configuration.Projects.Include == nil) {
+		log.Fatal("--all-projects requires the projects to include to be set in the configuration file")
+	}
 
-	{
-		verb := "Listing"
-		if !dryRun {
-			verb = "Deleting"
+	switch command {
+	case "":
+	case "plan":
+		if planOut == "" {
+			log.Fatal("plan requires --out")
 		}
-		log.Printf("%s everything older than %s\n", verb, bestBefore)
-	}
-	resources := make(chan Resource)
-	{
-		ao, eo, tlsConfig, err := clouds.Parse()
-		if err != nil {
-			panic(err)
+	case "destroy":
+		if len(clusterIDs) == 0 {
+			log.Fatal("destroy requires at least one --cluster-id")
 		}
-		providerClient, err := config.NewProviderClient(ctx, ao, config.WithTLSConfig(tlsConfig))
-		if err != nil {
-			panic(err)
+	case "explain":
+		if len(os.Args) < 4 || strings.HasPrefix(os.Args[2], "-") || strings.HasPrefix(os.Args[3], "-") {
+			log.Fatal("explain requires a resource type and a resource ID or name")
 		}
-
-		loadbalancerClient, err := openstack.NewLoadBalancerV2(providerClient, eo)
-		if err != nil {
-			// Ignore the error if Octavia is not available in the cloud
-			var gerr *gophercloud.ErrEndpointNotFound
-			if errors.As(err, &gerr) {
-				log.Println("Skipping load balancer listing because the Octavia endpoint was not found")
-			} else {
-				panic(err)
-			}
-			loadbalancerClient = nil
+		if err := validateResourceTypes([]string{os.Args[2]}, nil); err != nil {
+			log.Fatal(err)
 		}
-		computeClient, err := openstack.NewComputeV2(providerClient, eo)
-		if err != nil {
-			panic(err)
-		} else {
-			// Required for server tags
-			computeClient.Microversion = "2.26"
+	case "apply":
+		if len(os.Args) < 3 || strings.HasPrefix(os.Args[2], "-") {
+			log.Fatal("apply requires a plan file")
 		}
-		networkClient, err := openstack.NewNetworkV2(providerClient, eo)
-		if err != nil {
-			panic(err)
+		if len(cloudNames) > 1 || len(regionNames) > 1 || (len(regionNames) == 1 && regionNames[0] == "all") {
+			log.Fatal("apply supports a single cloud and region")
 		}
-		volumeClient, err := openstack.NewBlockStorageV3(providerClient, eo)
+		providerClient, eo, err := authenticate(ctx, cloudNames[0])
 		if err != nil {
-			panic(err)
+			log.Fatal(err)
 		}
-		identityClient, err := openstack.NewIdentityV3(providerClient, eo)
-		if err != nil {
-			panic(err)
+		if len(regionNames) == 1 {
+			eo.Region = regionNames[0]
 		}
-		imageClient, err := openstack.NewImageV2(providerClient, eo)
+		identityClient, err := openstack.NewIdentityV3(providerClient, eo)
 		if err != nil {
-			panic(err)
+			log.Fatal(err)
 		}
+		applyPlan(ctx, providerClient, identityClient, eo, os.Args[2])
+		return
+	default:
+		log.Fatalf("unknown command %q", command)
+	}
+	if command == "plan" && (len(cloudNames) > 1 || len(regionNames) > 1 || (len(regionNames) == 1 && regionNames[0] == "all")) {
+		log.Fatal("plan supports a single cloud and region")
+	}
 
-		containerClient, err := openstack.NewContainerV1(providerClient, eo)
-		if err != nil {
-			// Ignore the error if Swift is not available in the cloud
-			var gerr *gophercloud.ErrEndpointNotFound
-			if errors.As(err, &gerr) {
-				log.Println("Skipping container listing because the Swift endpoint was not found")
-			} else {
-				panic(err)
-			}
-			containerClient = nil
+	{
+		verb := "Listing"
+		if !dryRun && command != "plan" {
+			verb = "Deleting"
 		}
-
-		shareClient, err := openstack.NewSharedFileSystemV2(providerClient, eo)
-		if err != nil {
-			// Ignore the error if Manila is not available in the cloud
-			var gerr *gophercloud.ErrEndpointNotFound
-			if errors.As(err, &gerr) {
-				log.Println("Skipping share listing because the Manila endpoint was not found")
-			} else {
-				panic(err)
-			}
-			shareClient = nil
+		if command == "destroy" {
+			log.Printf("%s every resource of clusters %s\n", verb, strings.Join(clusterIDs, ", "))
+		} else {
+			log.Printf("%s everything older than %s\n", verb, bestBefore)
 		}
-
-		go func() {
-			defer close(resources)
-
-			if shouldProcessResource("floatingips") {
-				for res := range ListFloatingIPs(ctx, networkClient) {
-					resources <- res
-				}
-			}
-
-			if loadbalancerClient != nil && shouldProcessResource("loadbalancers") {
-				for res := range ListLoadBalancers(ctx, loadbalancerClient) {
-					resources <- res
-				}
-			}
-
-			if shouldProcessResource("servers") {
-				for res := range Filter(ListServers(ctx, computeClient), NameIsNot[Resource]("metrics")) {
-					resources <- res
-				}
-			}
-
-			if shouldProcessResource("routers") {
-				for res := range Filter(ListRouters(ctx, networkClient), NameIsNot[Resource]("dualstack")) {
-					resources <- res
+	}
+	now := time.Now()
+	report := Report{Time: now}
+	{
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for _, cloud := range cloudNames {
+			wg.Add(1)
+			go func(cloud string) {
+				defer wg.Done()
+				// A cloud that cannot be reached is reported, and does not
+				// prevent pruning the others.
+				providerClient, eo, err := authenticate(ctx, cloud)
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					report.AddError(CloudError{Cloud: cloud, Err: err})
+					return
 				}
-			}
-
-			if shouldProcessResource("trunks") {
-				for res := range ListTrunks(ctx, networkClient) {
-					resources <- res
+				identityClient, err := openstack.NewIdentityV3(providerClient, eo)
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					report.AddError(CloudError{Cloud: cloud, Err: fmt.Errorf("failed to create the identity client: %w", err)})
+					return
 				}
-			}
-
-			if shouldProcessResource("ports") {
-				for res := range ListPorts(ctx, networkClient) {
-					resources <- res
+				regions, err := regionsOf(ctx, providerClient, identityClient, eo)
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					report.AddError(CloudError{Cloud: cloud, Err: fmt.Errorf("failed to get the regions: %w", err)})
+					return
 				}
-			}
-
-			if shouldProcessResource("networks") {
-				for res := range Filter(ListNetworks(ctx, networkClient), NameDoesNotContain[Resource]("lb-mgmt-net", "octavia-provider-net", "hostonly", "external", "sahara-access", "mellanox", "intel", "public", "provider")) {
-					resources <- res
+				// Concurrency limits apply to the cloud as a whole.
+				pool := newWorkerPool(concurrency, serviceConcurrency)
+				for i, region := range regions {
+					// Application credentials are not regional: only list
+					// them once per cloud.
+					shouldProcess := shouldProcessResource
+					if i > 0 {
+						shouldProcess = func(resourceType string) bool {
+							return resourceType != "appcreds" && shouldProcessResource(resourceType)
+						}
+					}
+					regionalEO := eo
+					regionalEO.Region = region
+
+					wg.Add(1)
+					go func(region string) {
+						defer wg.Done()
+						regionReport := pruneRegion(ctx, providerClient, identityClient, regionalEO, shouldProcess, pool, now)
+						mu.Lock()
+						defer mu.Unlock()
+						report.Merge(regionReport, cloud, region)
+					}(region)
 				}
-			}
-
-			if shouldProcessResource("volumesnapshots") {
-				for res := range ListVolumeSnapshots(ctx, volumeClient) {
-					resources <- res
-				}
-			}
-
-			if shouldProcessResource("volumes") {
-				for res := range ListVolumes(ctx, volumeClient) {
-					resources <- res
-				}
-			}
+			}(cloud)
+		}
+		wg.Wait()
+	}
 
-			if shouldProcessResource("securitygroups") {
-				for res := range Filter(ListSecurityGroups(ctx, networkClient), NameIsNot[Resource]("default", "ssh", "allow_ssh", "allow_ping")) {
-					resources <- res
-				}
+	if command == "explain" {
+		explanations := report.Explanations.Of(os.Args[2], os.Args[3])
+		if explanations.Len() == 0 {
+			for _, err := range report.Errors {
+				log.Println(err)
 			}
+			log.Fatalf("%s %q was not listed", os.Args[2], os.Args[3])
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(explanations); err != nil {
+			panic(err)
+		}
+		return
+	}
 
-			if shareClient != nil && shouldProcessResource("shares") {
-				for res := range ListShares(ctx, shareClient) {
-					resources <- res
-				}
-			}
+	outputReport(report)
+}
 
-			if shouldProcessResource("appcreds") {
-				for res := range ListPerishableApplicationCredentials(ctx, identityClient) {
-					resources <- res
-				}
-			}
+// pruneRegion lists, selects and, depending on the command, deletes the
+// resources of the region given in the endpoint options. Deletions are run
+// by the worker pool of the cloud.
+func pruneRegion(ctx context.Context, providerClient *gophercloud.ProviderClient, identityClient *gophercloud.ServiceClient, eo gophercloud.EndpointOpts, shouldProcess func(resourceType string) bool, pool *workerPool, now time.Time) Report {
+	if eo.Region != "" {
+		log.Printf("Processing region %s\n", eo.Region)
+	}
+	report := Report{Time: now}
+	if explain || command == "explain" {
+		report.Explanations = NewExplanations()
+	}
+	listed := listResources(ctx, providerClient, identityClient, eo, shouldProcess, &report)
+	reasons := selectResources(listed, now, &report)
+
+	switch command {
+	case "explain":
+	case "plan":
+		if err := writePlan(planOut, now, report.Found, reasons); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		if !dryRun {
+			newDependencyGraph(report.Found).Delete(ctx, pool, &report)
+		}
+	}
+	return report
+}
 
-			if containerClient != nil && shouldProcessResource("containers") {
-				for res := range Filter(ListContainers(ctx, containerClient, ListNetworks(ctx, networkClient)), NameIsNot[Resource]("shiftstack-metrics", "shiftstack-bot")) {
-					resources <- res
-				}
+// selectResources adds to the report the listed resources that are to be
+// pruned, and returns the reason for pruning each of them by ID.
+func selectResources(listed []Resource, now time.Time, report *Report) map[string]string {
+	notKept := IsNotProtected(now)
+	for _, res := range listed {
+		if protected, expiresAt := protectionOf(res); protected && !expiresAt.IsZero() {
+			switch {
+			case !expiresAt.After(now):
+				report.AddExpiredProtection(res, expiresAt)
+			case expiresAt.Before(now.Add(protectionWarning)):
+				report.AddExpiringProtection(res, expiresAt)
 			}
+		}
+	}
 
-			if shouldProcessResource("images") {
-				for res := range Filter(ListImages(ctx, imageClient), NameMatchesOneOfThesePatterns[Resource](".{8}-.{5}-.{5}-ignition", ".{8}-.{5}-.{5}-rhcos", "bootstrap-ign-.{8}-.{5}-.{5}", "rhcos-.{7,8}-.{5}")) {
-					resources <- res
-				}
-			}
-		}()
+	isStale := OlderThanTTL(now)
+	staleFilter, staleReason, freshReason := "TTL", "older than the resource TTL", "younger than the resource TTL"
+	switch {
+	case command == "destroy":
+		isStale = ClusterIDIs(clusterIDs...)
+		staleFilter, staleReason, freshReason = "destroyed cluster", "belongs to a destroyed cluster", "does not belong to a destroyed cluster"
+	case byCluster:
+		isStale = ClusterCreatedBefore(listed, now.Add(-bestBefore), isStale)
+		staleFilter, staleReason, freshReason = "cluster TTL", "belongs to a stale cluster, or older than the resource TTL", "belongs to a live cluster, or younger than the resource TTL"
 	}
+	isOrphan := func(Resource) bool { return false }
+	if orphans && command != "destroy" {
+		if canDetectOrphans(report.Errors) {
+			isOrphan = IsOrphan(listed)
+		} else {
+			log.Println("Skipping orphan detection because servers and networks could not both be listed")
+		}
+	}
+	orphanCreatedBefore := CreatedBefore[Resource](now.Add(-orphanTTL))
 
-	now := time.Now()
-	report := Report{Time: now}
-	for staleResource := range Filter(resources, TagsDoNotContain("shiftstack-prune=keep"), CreatedBefore[Resource](now.Add(-bestBefore))) {
-		report.AddFound(staleResource)
+	implicitlyProtected := implicitProtections(listed, func(res Resource) bool { return !notKept(res) })
 
-		if !dryRun {
-			log.Printf("Deleting %s %q (created at %s)...\n", staleResource.Type(), staleResource.ID(), staleResource.CreatedAt().Format(time.RFC3339))
-			if err := staleResource.Delete(ctx); err != nil {
-				log.Printf("error deleting %s %q: %v\n", staleResource.Type(), staleResource.ID(), err)
-				report.AddFailedToDelete(staleResource)
-			} else {
-				log.Printf("deleted %s %q\n", staleResource.Type(), staleResource.ID())
-				report.AddDeleted(staleResource)
-			}
+	reasons := make(map[string]string)
+	for _, res := range listed {
+		if !notKept(res) {
+			report.Explanations.Record(res, "protection", false, "")
+			continue
 		}
+		if protectedBy, ok := implicitlyProtected[res.ID()]; ok {
+			report.Explanations.Record(res, "protection", false, fmt.Sprintf("needed by protected %s %q", protectedBy.Type(), protectedBy.ID()))
+			report.AddImplicitlyProtected(res, protectedBy)
+			continue
+		}
+		report.Explanations.Record(res, "protection", true, "")
+
+		orphan := isOrphan(res)
+		if orphan {
+			report.AddOrphan(res)
+		}
+		switch {
+		case isStale(res):
+			report.Explanations.Record(res, staleFilter, true, staleReason)
+			reasons[res.ID()] = staleReason
+		case orphan && orphanCreatedBefore(res):
+			report.Explanations.Record(res, "orphan TTL", true, "orphan older than the orphan TTL")
+			reasons[res.ID()] = "orphan older than the orphan TTL"
+		default:
+			report.Explanations.Record(res, staleFilter, false, freshReason)
+			continue
+		}
+		report.AddFound(res)
 	}
+	return reasons
+}
 
+// outputReport prints the report, notifies Slack of the resources that could
+// not be deleted and of the clouds that could not be pruned, and exits with a
+// non-zero status on listing errors.
+func outputReport(report Report) {
 	encoder := json.NewEncoder(os.Stdout)
 	if term.IsTerminal(int(os.Stdin.Fd())) {
 		encoder.SetIndent("", "  ")
@@ -390,12 +729,16 @@ func main() {
 		panic(err)
 	}
 
-	if len(report.FailedToDelete) > 0 && slackHook != "" {
+	if (len(report.FailedToDelete) > 0 || len(report.WentToError) > 0 || len(cloudErrors(report)) > 0) && slackHook != "" {
 		log.Printf("Sending failed_to_delete report to Slack")
 		if err := reportToSlack(slackHook, report); err != nil {
 			log.Fatalf("Failed to send a report to Slack: %v", err)
 		}
 	}
+
+	if len(report.Errors) > 0 {
+		os.Exit(exitListingFailed)
+	}
 }
 
 func init() {
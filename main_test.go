@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fakeResource is a Resource whose relations to other resources are given
+// by ID. Its deletions are recorded in its deletionLog, if any.
+type fakeResource struct {
+	id         string
+	name       string
+	typ        string
+	createdAt  time.Time
+	tags       []string
+	references []string
+	dependsOn  []string
+	attachedTo []string
+	deleteErr  error
+	log        *deletionLog
+}
+
+func (r fakeResource) CreatedAt() time.Time { return r.createdAt }
+func (r fakeResource) ID() string           { return r.id }
+func (r fakeResource) Tags() []string       { return r.tags }
+func (r fakeResource) References() []string { return r.references }
+func (r fakeResource) DependsOn() []string  { return r.dependsOn }
+func (r fakeResource) AttachedTo() []string { return r.attachedTo }
+
+func (r fakeResource) Name() string {
+	if r.name == "" {
+		return r.id
+	}
+	return r.name
+}
+
+func (r fakeResource) Type() string {
+	if r.typ == "" {
+		return "port"
+	}
+	return r.typ
+}
+
+func (r fakeResource) Delete(context.Context) error {
+	if r.log != nil {
+		r.log.add(r.id)
+	}
+	return r.deleteErr
+}
+
+// deletionLog records the order in which resources are deleted.
+type deletionLog struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (l *deletionLog) add(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ids = append(l.ids, id)
+}
+
+// idsOf returns the sorted IDs of the resources.
+func idsOf(resources []Resource) []string {
+	ids := make([]string, 0, len(resources))
+	for _, r := range resources {
+		ids = append(ids, r.ID())
+	}
+	sort.Strings(ids)
+	return ids
+}
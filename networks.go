@@ -35,6 +35,10 @@ func (s Network) Name() string {
 	return s.resource.Name
 }
 
+func (s Network) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s Network) Tags() []string {
 	return s.resource.Tags
 }
@@ -48,7 +52,7 @@ func (s Network) ClusterID() string {
 	return ""
 }
 
-func ListNetworks(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func ListNetworks(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
@@ -62,7 +66,7 @@ func ListNetworks(ctx context.Context, client *gophercloud.ServiceClient) <-chan
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "networks", Err: err}
 		}
 	}()
 	return ch
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// Plan is the content of a plan file, as written by "prune plan" and read by
+// "prune apply".
+type Plan struct {
+	Time      time.Time         `json:"timestamp"`
+	Resources []PlannedResource `json:"resources"`
+}
+
+type PlannedResource struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	ClusterID string    `json:"cluster_id,omitempty"`
+	Reason    string    `json:"reason"`
+
+	// State is a digest of the properties of the resource that must not
+	// change between plan and apply.
+	State string `json:"state"`
+}
+
+// stateOf digests the name, creation time, cluster, tags and metadata of
+// the resource.
+func stateOf(r Resource) string {
+	state := struct {
+		Name      string            `json:"name"`
+		CreatedAt time.Time         `json:"created_at"`
+		ClusterID string            `json:"cluster_id"`
+		Tags      []string          `json:"tags"`
+		Metadata  map[string]string `json:"metadata"`
+	}{
+		Name:      r.Name(),
+		CreatedAt: r.CreatedAt(),
+		ClusterID: clusterIDOf(r),
+	}
+	if tagger, ok := r.(Tagger); ok {
+		state.Tags = tagger.Tags()
+	}
+	if metadater, ok := r.(Metadater); ok {
+		state.Metadata = metadater.Metadata()
+	}
+
+	// Map keys are sorted by encoding/json, so the digest is stable.
+	b, err := json.Marshal(state)
+	if err != nil {
+		panic(err)
+	}
+	digest := sha256.Sum256(b)
+	return hex.EncodeToString(digest[:])
+}
+
+func writePlan(path string, now time.Time, found []Resource, reasons map[string]string) error {
+	plan := Plan{
+		Time:      now,
+		Resources: make([]PlannedResource, len(found)),
+	}
+	for i, r := range found {
+		plan.Resources[i] = PlannedResource{
+			Type:      r.Type(),
+			ID:        r.ID(),
+			Name:      r.Name(),
+			CreatedAt: r.CreatedAt(),
+			ClusterID: clusterIDOf(r),
+			Reason:    reasons[r.ID()],
+			State:     stateOf(r),
+		}
+	}
+
+	b, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write the plan: %w", err)
+	}
+	log.Printf("Wrote a plan to delete %d resources to %s\n", len(plan.Resources), path)
+	return nil
+}
+
+// applyPlan deletes the resources of the plan file. Every resource is listed
+// again first, and is not deleted if it changed or got protected since the
+// plan was written.
+func applyPlan(ctx context.Context, providerClient *gophercloud.ProviderClient, identityClient *gophercloud.ServiceClient, eo gophercloud.EndpointOpts, path string) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("failed to read the plan: %v", err)
+	}
+	var plan Plan
+	if err := json.Unmarshal(b, &plan); err != nil {
+		log.Fatalf("failed to parse the plan: %v", err)
+	}
+	log.Printf("Deleting the %d resources planned at %s\n", len(plan.Resources), plan.Time.Format(time.RFC3339))
+
+	plannedTypes := make(map[string]bool)
+	for _, planned := range plan.Resources {
+		plannedTypes[resourceTypeNames[planned.Type]] = true
+	}
+
+	now := time.Now()
+	report := Report{Time: now}
+	// Every processed type is listed, and not only the planned ones, so that
+	// a resource protected since the plan was written protects the planned
+	// resources it needs.
+	listed := listResources(ctx, providerClient, identityClient, eo, func(resourceType string) bool {
+		return plannedTypes[resourceType] || shouldProcessResource(resourceType)
+	}, &report)
+
+	notKept := IsNotProtected(now)
+	implicitlyProtected := implicitProtections(listed, func(res Resource) bool { return !notKept(res) })
+	current := make(map[[2]string]Resource, len(listed))
+	for _, res := range listed {
+		current[[2]string{res.Type(), res.ID()}] = res
+	}
+
+	refuse := func(res Resource, reason string) {
+		log.Printf("Refusing to delete %s %q: %s\n", res.Type(), res.ID(), reason)
+		report.AddRefused(res, reason)
+	}
+	for _, planned := range plan.Resources {
+		res, ok := current[[2]string{planned.Type, planned.ID}]
+		if !ok {
+			log.Printf("Skipping %s %q because it could not be found\n", planned.Type, planned.ID)
+			continue
+		}
+		if !notKept(res) {
+			refuse(res, "protected since the plan was written")
+			continue
+		}
+		if protectedBy, ok := implicitlyProtected[res.ID()]; ok {
+			refuse(res, fmt.Sprintf("protected by %s %q since the plan was written", protectedBy.Type(), protectedBy.ID()))
+			continue
+		}
+		if stateOf(res) != planned.State {
+			refuse(res, "changed since the plan was written")
+			continue
+		}
+		report.AddFound(res)
+	}
+
+	newDependencyGraph(report.Found).Delete(ctx, newWorkerPool(concurrency, serviceConcurrency), &report)
+	outputReport(report)
+}
@@ -0,0 +1,65 @@
+package main
+
+// resourceServices maps the value returned by Typer.Type() to the OpenStack
+// service that serves the resource.
+var resourceServices = map[string]string{
+	"application credential": "keystone",
+	"container":              "swift",
+	"floating ip":            "neutron",
+	"image":                  "glance",
+	"key":                    "nova",
+	"load balancer":          "octavia",
+	"network":                "neutron",
+	"port":                   "neutron",
+	"router":                 "neutron",
+	"security group":         "neutron",
+	"server":                 "nova",
+	"server group":           "nova",
+	"share":                  "manila",
+	"subnet":                 "neutron",
+	"trunk":                  "neutron",
+	"volume":                 "cinder",
+	"volume snapshot":        "cinder",
+}
+
+func isService(service string) bool {
+	for _, s := range resourceServices {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// workerPool bounds the number of concurrent deletions, both globally and
+// per OpenStack service.
+type workerPool struct {
+	global   chan struct{}
+	services map[string]chan struct{}
+}
+
+func newWorkerPool(global int, perService map[string]int) *workerPool {
+	p := &workerPool{
+		global:   make(chan struct{}, global),
+		services: make(map[string]chan struct{}, len(perService)),
+	}
+	for service, limit := range perService {
+		p.services[service] = make(chan struct{}, limit)
+	}
+	return p
+}
+
+// Do runs fn once a slot is available for the service serving the resource,
+// and globally. It blocks until fn returns.
+func (p *workerPool) Do(r Resource, fn func()) {
+	// Acquire the service slot first, so that resources waiting on a busy
+	// service do not hold global slots.
+	if service, ok := p.services[resourceServices[r.Type()]]; ok {
+		service <- struct{}{}
+		defer func() { <-service }()
+	}
+	p.global <- struct{}{}
+	defer func() { <-p.global }()
+
+	fn()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"time"
 
@@ -19,8 +20,13 @@ func (s Port) CreatedAt() time.Time {
 	return s.resource.CreatedAt
 }
 
+// Delete succeeds if the port is already gone, as the ports of a trunk are
+// deleted along with it.
 func (s Port) Delete(ctx context.Context) error {
-	return ports.Delete(ctx, s.client, s.resource.ID).ExtractErr()
+	if err := ports.Delete(ctx, s.client, s.resource.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return err
+	}
+	return nil
 }
 
 func (s Port) Type() string {
@@ -35,6 +41,10 @@ func (s Port) Name() string {
 	return s.resource.Name
 }
 
+func (s Port) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s Port) Tags() []string {
 	return s.resource.Tags
 }
@@ -48,16 +58,28 @@ func (s Port) ClusterID() string {
 	return ""
 }
 
-func ListPorts(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s Port) References() []string {
+	references := append([]string{s.resource.NetworkID}, s.resource.SecurityGroups...)
+	for _, fixedIP := range s.resource.FixedIPs {
+		references = append(references, fixedIP.SubnetID)
+	}
+	return references
+}
+
+func (s Port) DependsOn() []string {
+	if s.resource.DeviceID != "" {
+		return []string{s.resource.DeviceID}
+	}
+	return nil
+}
+
+func ListPorts(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
 		if err := ports.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := ports.ExtractPorts(page)
 			for i := range resources {
-				if isOpenStackManaged(resources[i]) {
-					continue
-				}
 				ch <- Port{
 					resource: &resources[i],
 					client:   client,
@@ -65,12 +87,21 @@ func ListPorts(ctx context.Context, client *gophercloud.ServiceClient) <-chan Re
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "ports", Err: err}
 		}
 	}()
 	return ch
 }
 
+// IsNotOpenStackManaged selects the ports that are not managed by OpenStack
+// itself, such as router interfaces and DHCP ports.
+func IsNotOpenStackManaged(resource Resource) bool {
+	if port, ok := resource.(Port); ok {
+		return !isOpenStackManaged(*port.resource)
+	}
+	return true
+}
+
 func isOpenStackManaged(port ports.Port) bool {
 	deviceOwnerPrefixes := []string{"network:", "neutron:"}
 	for _, prefix := range deviceOwnerPrefixes {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+)
+
+// allProjects makes the listers use the admin options listing the resources
+// of every project, rather than those of the authenticated project.
+var allProjects = func() bool {
+	for _, arg := range os.Args {
+		if arg == "--all-projects" {
+			return true
+		}
+	}
+	return false
+}()
+
+// Project is a Keystone project, as matched by the projects rules of the
+// configuration.
+type Project struct {
+	resource *projects.Project
+}
+
+func (p Project) ID() string {
+	return p.resource.ID
+}
+
+func (p Project) Name() string {
+	return p.resource.Name
+}
+
+func (p Project) Tags() []string {
+	return p.resource.Tags
+}
+
+// Accept reports whether the resources of the project are processed
+// according to the configuration.
+func (c ProjectConfig) Accept(project Project) bool {
+	if c.Include != nil && !c.Include.Match(project) {
+		return false
+	}
+	if c.Exclude != nil && c.Exclude.Match(project) {
+		return false
+	}
+	return true
+}
+
+// InSelectedProjects returns a filter function accepting the resources of
+// the projects selected by the configuration, and the resources that do not
+// belong to a project. If the projects cannot be listed, the error is sent
+// on errs and no project is selected.
+func InSelectedProjects(ctx context.Context, client *gophercloud.ServiceClient, projectConfig ProjectConfig, errs chan<- error) func(Resource) bool {
+	selected := make(map[string]bool)
+	if err := projects.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		resources, err := projects.ExtractProjects(page)
+		for i := range resources {
+			if projectConfig.Accept(Project{resource: &resources[i]}) {
+				selected[resources[i].ID] = true
+			}
+		}
+		return true, err
+	}); err != nil {
+		errs <- ListError{ResourceType: "projects", Err: err}
+		selected = nil
+	}
+
+	return func(resource Resource) bool {
+		if projecter, ok := resource.(Projecter); ok {
+			return selected[projecter.ProjectID()]
+		}
+		return true
+	}
+}
+
+// getProjectID returns the ID of the project the client is authenticated in.
+func getProjectID(ctx context.Context, client *gophercloud.ServiceClient) (string, error) {
+	var token struct {
+		Project struct {
+			ID string `json:"id"`
+		} `json:"project"`
+	}
+	err := tokens.Get(ctx, client, client.Token()).ExtractInto(&token)
+	return token.Project.ID, err
+}
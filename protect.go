@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// protectionKey is the tag prefix, or the metadata key, protecting a
+// resource from pruning. The protection can be:
+//
+//	keep                       forever
+//	keep-until=<RFC3339 time>  until the given time
+//	ttl=<Go duration>          until the given duration after creation
+//
+// For example, the tag "shiftstack-prune=keep" or the metadata
+// "shiftstack-prune: keep".
+const protectionKey = "shiftstack-prune"
+
+// protectionsOf returns the protections set on the resource, as tags or as
+// metadata.
+func protectionsOf(r Resource) []string {
+	var protections []string
+	if tagger, ok := r.(Tagger); ok {
+		for _, tag := range tagger.Tags() {
+			if value := strings.TrimPrefix(tag, protectionKey+"="); value != tag {
+				protections = append(protections, value)
+			}
+		}
+	}
+	if metadater, ok := r.(Metadater); ok {
+		if value, ok := metadater.Metadata()[protectionKey]; ok {
+			protections = append(protections, value)
+		}
+	}
+	return protections
+}
+
+// protectionOf returns whether the resource is protected, and when the
+// protection expires. The expiry time is zero for resources protected
+// forever. When several protections are set, the longest wins. Malformed
+// protections protect forever, so that a typo never gets a resource deleted.
+func protectionOf(r Resource) (protected bool, expiresAt time.Time) {
+	for _, value := range protectionsOf(r) {
+		var until time.Time
+		switch {
+		case value == "keep":
+		case strings.HasPrefix(value, "keep-until="):
+			t, err := time.Parse(time.RFC3339, strings.TrimPrefix(value, "keep-until="))
+			if err != nil {
+				log.Printf("invalid protection %q on %s %q, keeping it: %v\n", value, r.Type(), r.ID(), err)
+				break
+			}
+			until = t
+		case strings.HasPrefix(value, "ttl="):
+			d, err := time.ParseDuration(strings.TrimPrefix(value, "ttl="))
+			if err != nil {
+				log.Printf("invalid protection %q on %s %q, keeping it: %v\n", value, r.Type(), r.ID(), err)
+				break
+			}
+			until = r.CreatedAt().Add(d)
+		default:
+			log.Printf("unknown protection %q on %s %q, keeping it\n", value, r.Type(), r.ID())
+		}
+
+		if until.IsZero() {
+			return true, time.Time{}
+		}
+		if !protected || until.After(expiresAt) {
+			protected, expiresAt = true, until
+		}
+	}
+	return protected, expiresAt
+}
+
+// IsNotProtected selects the resources that are not protected from pruning
+// at the given time.
+func IsNotProtected(now time.Time) func(Resource) bool {
+	return func(resource Resource) bool {
+		protected, expiresAt := protectionOf(resource)
+		return !protected || (!expiresAt.IsZero() && !expiresAt.After(now))
+	}
+}
+
+// implicitProtections propagates the protection of the protected resources
+// to what they need to stay usable: the resources they reference, the
+// resources attached to them, and the attachers providing them connectivity.
+// For example, a protected server protects its ports and volumes, which in
+// turn protect their network, security groups, floating IPs and router. It
+// returns, by ID, the protected resource causing each implicit protection.
+func implicitProtections(resources []Resource, isProtected func(Resource) bool) map[string]Resource {
+	byID := make(map[string]Resource, len(resources))
+	dependents := make(map[string][]Resource)
+	attachers := make(map[string][]Resource)
+	for _, r := range resources {
+		byID[r.ID()] = r
+		if dependent, ok := r.(Dependent); ok {
+			for _, id := range dependent.DependsOn() {
+				dependents[id] = append(dependents[id], r)
+			}
+		}
+		if attacher, ok := r.(Attacher); ok {
+			for _, id := range attacher.AttachedTo() {
+				attachers[id] = append(attachers[id], r)
+			}
+		}
+	}
+
+	causes := make(map[string]Resource)
+	type protection struct{ resource, cause Resource }
+	var queue []protection
+	for _, r := range resources {
+		if isProtected(r) {
+			queue = append(queue, protection{resource: r, cause: r})
+		}
+	}
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if visited[p.resource.ID()] {
+			continue
+		}
+		visited[p.resource.ID()] = true
+		if p.resource.ID() != p.cause.ID() {
+			causes[p.resource.ID()] = p.cause
+		}
+
+		var next []Resource
+		if referrer, ok := p.resource.(Referrer); ok {
+			for _, id := range referrer.References() {
+				if r, ok := byID[id]; ok {
+					next = append(next, r)
+				}
+			}
+		}
+		next = append(next, dependents[p.resource.ID()]...)
+		next = append(next, attachers[p.resource.ID()]...)
+		for _, r := range next {
+			if !visited[r.ID()] {
+				queue = append(queue, protection{resource: r, cause: p.cause})
+			}
+		}
+	}
+	return causes
+}
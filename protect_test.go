@@ -0,0 +1,207 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProtectionOf(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-2 * time.Hour)
+
+	for _, tc := range [...]struct {
+		name              string
+		tags              []string
+		expectedProtected bool
+		expectedExpiresAt time.Time
+		expectedNotKept   bool
+	}{
+		{
+			name:            "no protection",
+			tags:            []string{"openshiftClusterID=abc"},
+			expectedNotKept: true,
+		},
+		{
+			name:              "keep",
+			tags:              []string{"shiftstack-prune=keep"},
+			expectedProtected: true,
+		},
+		{
+			name:              "keep-until in the future",
+			tags:              []string{"shiftstack-prune=keep-until=2024-06-02T00:00:00Z"},
+			expectedProtected: true,
+			expectedExpiresAt: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "expired keep-until",
+			tags:              []string{"shiftstack-prune=keep-until=2024-05-01T00:00:00Z"},
+			expectedProtected: true,
+			expectedExpiresAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			expectedNotKept:   true,
+		},
+		{
+			name:              "ttl",
+			tags:              []string{"shiftstack-prune=ttl=3h"},
+			expectedProtected: true,
+			expectedExpiresAt: createdAt.Add(3 * time.Hour),
+		},
+		{
+			name:              "expired ttl",
+			tags:              []string{"shiftstack-prune=ttl=1h"},
+			expectedProtected: true,
+			expectedExpiresAt: createdAt.Add(time.Hour),
+			expectedNotKept:   true,
+		},
+		{
+			name:              "malformed keep-until",
+			tags:              []string{"shiftstack-prune=keep-until=tomorrow"},
+			expectedProtected: true,
+		},
+		{
+			name:              "malformed ttl",
+			tags:              []string{"shiftstack-prune=ttl=3 days"},
+			expectedProtected: true,
+		},
+		{
+			name:              "unknown protection",
+			tags:              []string{"shiftstack-prune=forever"},
+			expectedProtected: true,
+		},
+		{
+			name:              "longest protection wins",
+			tags:              []string{"shiftstack-prune=ttl=1h", "shiftstack-prune=keep-until=2024-06-02T00:00:00Z"},
+			expectedProtected: true,
+			expectedExpiresAt: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "keep wins over expiring protections",
+			tags:              []string{"shiftstack-prune=ttl=1h", "shiftstack-prune=keep"},
+			expectedProtected: true,
+		},
+		{
+			name:            "other prefix",
+			tags:            []string{"shiftstack-prune-keep"},
+			expectedNotKept: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := fakeResource{id: "port", createdAt: createdAt, tags: tc.tags}
+
+			protected, expiresAt := protectionOf(r)
+			if protected != tc.expectedProtected {
+				t.Errorf("expected protected %t, got %t", tc.expectedProtected, protected)
+			}
+			if !expiresAt.Equal(tc.expectedExpiresAt) {
+				t.Errorf("expected expiry %s, got %s", tc.expectedExpiresAt, expiresAt)
+			}
+			if notKept := IsNotProtected(now)(r); notKept != tc.expectedNotKept {
+				t.Errorf("expected IsNotProtected %t, got %t", tc.expectedNotKept, notKept)
+			}
+		})
+	}
+}
+
+func TestImplicitProtections(t *testing.T) {
+	keep := []string{"shiftstack-prune=keep"}
+	for _, tc := range [...]struct {
+		name      string
+		resources []fakeResource
+
+		// expected maps the ID of every implicitly protected resource to
+		// the ID of the protected resource causing it.
+		expected map[string]string
+	}{
+		{
+			name: "nothing protected",
+			resources: []fakeResource{
+				{id: "server", typ: "server", references: []string{"port"}},
+				{id: "port"},
+			},
+			expected: map[string]string{},
+		},
+		{
+			name: "references are protected transitively",
+			resources: []fakeResource{
+				{id: "server", typ: "server", tags: keep, references: []string{"port", "volume"}},
+				{id: "port", references: []string{"network", "security group"}},
+				{id: "volume", typ: "volume"},
+				{id: "network", typ: "network"},
+				{id: "security group", typ: "security group"},
+				{id: "other port", references: []string{"network"}},
+			},
+			expected: map[string]string{
+				"port":           "server",
+				"volume":         "server",
+				"network":        "server",
+				"security group": "server",
+			},
+		},
+		{
+			name: "referrers are not protected",
+			resources: []fakeResource{
+				{id: "port", references: []string{"network"}},
+				{id: "network", typ: "network", tags: keep},
+			},
+			expected: map[string]string{},
+		},
+		{
+			name: "dependents are protected",
+			resources: []fakeResource{
+				{id: "server", typ: "server", tags: keep},
+				{id: "group", typ: "server group", dependsOn: []string{"server"}},
+			},
+			expected: map[string]string{"group": "server"},
+		},
+		{
+			name: "attachers are protected",
+			resources: []fakeResource{
+				{id: "network", typ: "network", tags: keep},
+				{id: "subnet", typ: "subnet", references: []string{"network"}, attachedTo: []string{"network"}},
+				{id: "router", typ: "router", references: []string{"subnet"}, attachedTo: []string{"subnet"}},
+			},
+			expected: map[string]string{
+				"subnet": "network",
+				"router": "network",
+			},
+		},
+		{
+			name: "expired protections do not propagate",
+			resources: []fakeResource{
+				{id: "server", typ: "server", tags: []string{"shiftstack-prune=keep-until=2000-01-01T00:00:00Z"}, references: []string{"port"}},
+				{id: "port"},
+			},
+			expected: map[string]string{},
+		},
+		{
+			name: "cycles terminate",
+			resources: []fakeResource{
+				{id: "a", tags: keep, references: []string{"b"}},
+				{id: "b", references: []string{"c"}},
+				{id: "c", references: []string{"a", "b"}},
+			},
+			expected: map[string]string{
+				"b": "a",
+				"c": "a",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resources := make([]Resource, len(tc.resources))
+			for i := range tc.resources {
+				resources[i] = tc.resources[i]
+			}
+			notKept := IsNotProtected(time.Now())
+
+			causes := implicitProtections(resources, func(r Resource) bool { return !notKept(r) })
+
+			got := make(map[string]string, len(causes))
+			for id, cause := range causes {
+				got[id] = cause.ID()
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
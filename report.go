@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -12,12 +14,57 @@ type Report struct {
 	Found          resources `json:"found"`
 	Deleted        resources `json:"deleted"`
 	FailedToDelete resources `json:"failed_to_delete"`
+	StillDeleting  resources `json:"still_deleting,omitempty"`
+	WentToError    resources `json:"went_to_error,omitempty"`
+	Errors         []error   `json:"errors,omitempty"`
+	Orphans        resources `json:"orphans,omitempty"`
+
+	// ExpiredProtections and ExpiringProtections hold the resources whose
+	// time-bounded protection from pruning has expired, or is about to.
+	ExpiredProtections  protections `json:"expired_protections,omitempty"`
+	ExpiringProtections protections `json:"expiring_protections,omitempty"`
+
+	// ImplicitlyProtected holds the resources that were not pruned because
+	// a protected resource depends on them.
+	ImplicitlyProtected implicitProtection `json:"implicitly_protected,omitempty"`
+
+	// Refused holds the planned resources that "prune apply" did not
+	// delete.
+	Refused refusals `json:"refused,omitempty"`
+
+	// Explanations holds, with --explain, the decisions taken on every
+	// listed resource.
+	Explanations *Explanations `json:"explanations,omitempty"`
+
+	// DeleteAttempts holds, by resource ID, the history of the deletion
+	// attempts of the resources that did not go through at the first try.
+	DeleteAttempts map[string][]deleteAttempt `json:"delete_attempts,omitempty"`
 }
 
 func (rep *Report) AddFound(r Resource) {
 	rep.Found = append(rep.Found, r)
 }
 
+func (rep *Report) AddOrphan(r Resource) {
+	rep.Orphans = append(rep.Orphans, r)
+}
+
+func (rep *Report) AddExpiredProtection(r Resource, expiresAt time.Time) {
+	rep.ExpiredProtections = append(rep.ExpiredProtections, protectedResource{Resource: r, ExpiresAt: expiresAt})
+}
+
+func (rep *Report) AddExpiringProtection(r Resource, expiresAt time.Time) {
+	rep.ExpiringProtections = append(rep.ExpiringProtections, protectedResource{Resource: r, ExpiresAt: expiresAt})
+}
+
+func (rep *Report) AddImplicitlyProtected(r, protectedBy Resource) {
+	rep.ImplicitlyProtected = append(rep.ImplicitlyProtected, implicitlyProtectedResource{Resource: r, ProtectedBy: protectedBy})
+}
+
+func (rep *Report) AddRefused(r Resource, reason string) {
+	rep.Refused = append(rep.Refused, refusedResource{Resource: r, Reason: reason})
+}
+
 func (rep *Report) AddDeleted(r Resource) {
 	rep.Deleted = append(rep.Deleted, r)
 }
@@ -26,29 +73,258 @@ func (rep *Report) AddFailedToDelete(r Resource) {
 	rep.FailedToDelete = append(rep.FailedToDelete, r)
 }
 
-func (res resources) MarshalJSON() ([]byte, error) {
-	type resourcePrinter struct {
-		ClusterID string    `json:"cluster_id,omitempty"`
-		ID        string    `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		Name      string    `json:"name"`
-		Type      string    `json:"type"`
+func (rep *Report) AddError(err error) {
+	rep.Errors = append(rep.Errors, err)
+}
+
+func (rep *Report) AddStillDeleting(r Resource) {
+	rep.StillDeleting = append(rep.StillDeleting, r)
+}
+
+func (rep *Report) AddWentToError(r Resource) {
+	rep.WentToError = append(rep.WentToError, r)
+}
+
+func (rep *Report) AddDeleteAttempts(r Resource, attempts []deleteAttempt) {
+	if len(attempts) == 1 && attempts[0].Error == "" {
+		return
+	}
+	if rep.DeleteAttempts == nil {
+		rep.DeleteAttempts = make(map[string][]deleteAttempt)
+	}
+	rep.DeleteAttempts[r.ID()] = attempts
+}
+
+// Merge adds the content of a report of the given cloud and region to rep.
+// Every resource is marked as belonging to them, unless both are empty.
+func (rep *Report) Merge(other Report, cloud, region string) {
+	locate := func(r Resource) Resource {
+		if cloud == "" && region == "" {
+			return r
+		}
+		return locatedResource{Resource: r, Cloud: cloud, Region: region}
+	}
+	locateAll := func(res resources) resources {
+		located := make(resources, len(res))
+		for i := range res {
+			located[i] = locate(res[i])
+		}
+		return located
 	}
 
-	printers := make([]resourcePrinter, len(res))
+	rep.Found = append(rep.Found, locateAll(other.Found)...)
+	rep.Deleted = append(rep.Deleted, locateAll(other.Deleted)...)
+	rep.FailedToDelete = append(rep.FailedToDelete, locateAll(other.FailedToDelete)...)
+	rep.StillDeleting = append(rep.StillDeleting, locateAll(other.StillDeleting)...)
+	rep.WentToError = append(rep.WentToError, locateAll(other.WentToError)...)
+	rep.Orphans = append(rep.Orphans, locateAll(other.Orphans)...)
+	for _, err := range other.Errors {
+		var listErr ListError
+		if errors.As(err, &listErr) {
+			listErr.Cloud, listErr.Region = cloud, region
+			err = listErr
+		}
+		rep.AddError(err)
+	}
+	for _, p := range other.ExpiredProtections {
+		rep.AddExpiredProtection(locate(p.Resource), p.ExpiresAt)
+	}
+	for _, p := range other.ExpiringProtections {
+		rep.AddExpiringProtection(locate(p.Resource), p.ExpiresAt)
+	}
+	for _, p := range other.ImplicitlyProtected {
+		rep.AddImplicitlyProtected(locate(p.Resource), p.ProtectedBy)
+	}
+	for _, r := range other.Refused {
+		rep.AddRefused(locate(r.Resource), r.Reason)
+	}
+	if other.Explanations != nil {
+		if rep.Explanations == nil {
+			rep.Explanations = NewExplanations()
+		}
+		for _, r := range other.Explanations.resources {
+			for _, decision := range other.Explanations.decisions[[2]string{r.Type(), r.ID()}] {
+				rep.Explanations.Record(locate(r), decision.Filter, decision.Accepted, decision.Detail)
+			}
+		}
+	}
+	for id, attempts := range other.DeleteAttempts {
+		if rep.DeleteAttempts == nil {
+			rep.DeleteAttempts = make(map[string][]deleteAttempt)
+		}
+		rep.DeleteAttempts[id] = attempts
+	}
+}
+
+type resourcePrinter struct {
+	Cloud     string    `json:"cloud,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	ClusterID string    `json:"cluster_id,omitempty"`
+	ProjectID string    `json:"project_id,omitempty"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+}
+
+func printResource(r Resource) resourcePrinter {
+	if located, ok := r.(locatedResource); ok {
+		printer := printResource(located.Resource)
+		printer.Cloud, printer.Region = located.Cloud, located.Region
+		return printer
+	}
+	printer := resourcePrinter{
+		ClusterID: clusterIDOf(r),
+		ID:        r.ID(),
+		CreatedAt: r.CreatedAt(),
+		Name:      r.Name(),
+		Type:      r.Type(),
+	}
+	if projecter, ok := r.(Projecter); ok {
+		printer.ProjectID = projecter.ProjectID()
+	}
+	return printer
+}
 
+func (res resources) MarshalJSON() ([]byte, error) {
+	printers := make([]resourcePrinter, len(res))
 	for i := range res {
-		var clusterID string
-		if c, ok := res[i].(Clusterer); ok {
-			clusterID = c.ClusterID()
+		printers[i] = printResource(res[i])
+	}
+	return json.Marshal(printers)
+}
+
+// protectedResource is a resource whose protection from pruning expires.
+type protectedResource struct {
+	Resource
+	ExpiresAt time.Time
+}
+
+type protections []protectedResource
+
+func (p protections) MarshalJSON() ([]byte, error) {
+	type protectionPrinter struct {
+		resourcePrinter
+		ExpiresAt time.Time `json:"protection_expires_at"`
+	}
+
+	printers := make([]protectionPrinter, len(p))
+	for i := range p {
+		printers[i] = protectionPrinter{
+			resourcePrinter: printResource(p[i].Resource),
+			ExpiresAt:       p[i].ExpiresAt,
 		}
-		printers[i] = resourcePrinter{
-			ClusterID: clusterID,
-			ID:        res[i].ID(),
-			CreatedAt: res[i].CreatedAt(),
-			Name:      res[i].Name(),
-			Type:      res[i].Type(),
+	}
+	return json.Marshal(printers)
+}
+
+type implicitlyProtectedResource struct {
+	Resource
+	ProtectedBy Resource
+}
+
+type implicitProtection []implicitlyProtectedResource
+
+func (p implicitProtection) MarshalJSON() ([]byte, error) {
+	type protectionPrinter struct {
+		resourcePrinter
+		ProtectedBy struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"protected_by"`
+	}
+
+	printers := make([]protectionPrinter, len(p))
+	for i := range p {
+		printers[i].resourcePrinter = printResource(p[i].Resource)
+		printers[i].ProtectedBy.ID = p[i].ProtectedBy.ID()
+		printers[i].ProtectedBy.Type = p[i].ProtectedBy.Type()
+	}
+	return json.Marshal(printers)
+}
+
+type refusedResource struct {
+	Resource
+	Reason string
+}
+
+type refusals []refusedResource
+
+func (r refusals) MarshalJSON() ([]byte, error) {
+	type refusalPrinter struct {
+		resourcePrinter
+		Reason string `json:"reason"`
+	}
+
+	printers := make([]refusalPrinter, len(r))
+	for i := range r {
+		printers[i] = refusalPrinter{
+			resourcePrinter: printResource(r[i].Resource),
+			Reason:          r[i].Reason,
 		}
 	}
 	return json.Marshal(printers)
 }
+
+// ListError is reported when listing a resource type fails. The other
+// resource types are still processed.
+type ListError struct {
+	Cloud        string
+	Region       string
+	ResourceType string
+	Err          error
+}
+
+func (e ListError) Error() string {
+	var location string
+	if e.Cloud != "" {
+		location += " in cloud " + e.Cloud
+	}
+	if e.Region != "" {
+		location += " in region " + e.Region
+	}
+	return fmt.Sprintf("failed to list %s%s: %v", e.ResourceType, location, e.Err)
+}
+
+func (e ListError) Unwrap() error {
+	return e.Err
+}
+
+func (e ListError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Cloud        string `json:"cloud,omitempty"`
+		Region       string `json:"region,omitempty"`
+		ResourceType string `json:"resource_type"`
+		Error        string `json:"error"`
+	}{
+		Cloud:        e.Cloud,
+		Region:       e.Region,
+		ResourceType: e.ResourceType,
+		Error:        e.Err.Error(),
+	})
+}
+
+// CloudError is reported when a cloud cannot be pruned at all, for example
+// because authentication fails. The other clouds are still processed.
+type CloudError struct {
+	Cloud string
+	Err   error
+}
+
+func (e CloudError) Error() string {
+	return fmt.Sprintf("failed to process cloud %q: %v", e.Cloud, e.Err)
+}
+
+func (e CloudError) Unwrap() error {
+	return e.Err
+}
+
+func (e CloudError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Cloud string `json:"cloud"`
+		Error string `json:"error"`
+	}{
+		Cloud: e.Cloud,
+		Error: e.Err.Error(),
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+const (
+	retryInitialBackoff = 2 * time.Second
+	retryMaxBackoff     = time.Minute
+)
+
+// deleteAttempt records the outcome of one call to Delete.
+type deleteAttempt struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+// isRetryable reports whether a Delete that failed with err may succeed if
+// attempted again later.
+func isRetryable(err error) bool {
+	for _, code := range []int{
+		http.StatusConflict,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	} {
+		if gophercloud.ResponseCodeIs(err, code) {
+			return true
+		}
+	}
+	// Octavia refuses changes to load balancers in an immutable
+	// PENDING_* provisioning status until the pending operation is done.
+	return strings.Contains(err.Error(), "PENDING_")
+}
+
+// deleteWithRetry deletes the resource, retrying with exponential backoff
+// as long as the error is retryable and maxAttempts is not reached. Each
+// attempt waits for a slot in the pool. It returns the history of the
+// attempts along with the error of the last one.
+func deleteWithRetry(ctx context.Context, r Resource, pool *workerPool, maxAttempts int) ([]deleteAttempt, error) {
+	var attempts []deleteAttempt
+	backoff := retryInitialBackoff
+	for {
+		err := deleteInPool(ctx, r, pool)
+
+		attempt := deleteAttempt{Time: time.Now()}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		attempts = append(attempts, attempt)
+
+		if err == nil || !isRetryable(err) || len(attempts) >= maxAttempts {
+			return attempts, err
+		}
+
+		log.Printf("retrying deletion of %s %q in %s\n", r.Type(), r.ID(), backoff)
+		select {
+		case <-ctx.Done():
+			return attempts, err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, retryMaxBackoff)
+	}
+}
+
+// deleteInPool deletes the resource in a slot of the pool. A Detacher is
+// detached in a slot of its own first, and its detachment is waited for
+// outside of any slot, so that slow detachments do not hold up the other
+// deletions.
+func deleteInPool(ctx context.Context, r Resource, pool *workerPool) error {
+	var err error
+	if detacher, ok := r.(Detacher); ok {
+		pool.Do(r, func() { err = detacher.Detach(ctx) })
+		if err == nil {
+			err = detacher.WaitUntilDetached(ctx)
+		}
+		if err != nil {
+			log.Printf("error detaching %s %q: %v\n", r.Type(), r.ID(), err)
+			return err
+		}
+	}
+	pool.Do(r, func() { err = deleteResource(ctx, r) })
+	return err
+}
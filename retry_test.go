@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+func TestIsRetryable(t *testing.T) {
+	responseCode := func(code int) error {
+		return gophercloud.ErrUnexpectedResponseCode{Actual: code}
+	}
+	for _, tc := range [...]struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"conflict", responseCode(http.StatusConflict), true},
+		{"too many requests", responseCode(http.StatusTooManyRequests), true},
+		{"internal server error", responseCode(http.StatusInternalServerError), true},
+		{"bad gateway", responseCode(http.StatusBadGateway), true},
+		{"service unavailable", responseCode(http.StatusServiceUnavailable), true},
+		{"gateway timeout", responseCode(http.StatusGatewayTimeout), true},
+		{"wrapped conflict", fmt.Errorf("failed to delete: %w", responseCode(http.StatusConflict)), true},
+		{"Octavia pending status", errors.New("Invalid state PENDING_UPDATE of loadbalancer resource"), true},
+		{"bad request", responseCode(http.StatusBadRequest), false},
+		{"forbidden", responseCode(http.StatusForbidden), false},
+		{"not found", responseCode(http.StatusNotFound), false},
+		{"not implemented", responseCode(http.StatusNotImplemented), false},
+		{"other error", errors.New("connection refused"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryable(tc.err); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
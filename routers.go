@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/portforwarding"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/routers"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
 	"github.com/gophercloud/gophercloud/v2/pagination"
@@ -20,13 +24,65 @@ func (s Router) CreatedAt() time.Time {
 	return s.resource.CreatedAt
 }
 
+// Delete tears the router down step by step: it clears its static routes,
+// deletes the port forwardings of its floating IPs, clears its external
+// gateway and removes its interfaces before deleting it. The returned error
+// names the step that failed.
 func (s Router) Delete(ctx context.Context) error {
-	for _, subnet := range s.resource.subnets {
-		if _, err := routers.RemoveInterface(ctx, s.client, s.resource.ID, routers.RemoveInterfaceOpts{SubnetID: subnet}).Extract(); err != nil {
-			return err
+	if len(s.resource.Routes) > 0 {
+		if _, err := routers.Update(ctx, s.client, s.resource.ID, routers.UpdateOpts{Routes: &[]routers.Route{}}).Extract(); err != nil {
+			return fmt.Errorf("failed to clear the routes of router %q: %w", s.resource.ID, err)
 		}
 	}
-	return routers.Delete(ctx, s.client, s.resource.ID).ExtractErr()
+
+	if err := s.deletePortForwardings(ctx); err != nil {
+		return fmt.Errorf("failed to delete the port forwardings of router %q: %w", s.resource.ID, err)
+	}
+
+	if s.resource.GatewayInfo.NetworkID != "" {
+		if _, err := routers.Update(ctx, s.client, s.resource.ID, routers.UpdateOpts{GatewayInfo: &routers.GatewayInfo{}}).Extract(); err != nil {
+			return fmt.Errorf("failed to clear the external gateway of router %q: %w", s.resource.ID, err)
+		}
+	}
+
+	for _, port := range s.resource.interfaces {
+		if _, err := routers.RemoveInterface(ctx, s.client, s.resource.ID, routers.RemoveInterfaceOpts{PortID: port}).Extract(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to remove interface %q from router %q: %w", port, s.resource.ID, err)
+		}
+	}
+
+	if err := routers.Delete(ctx, s.client, s.resource.ID).ExtractErr(); err != nil {
+		return fmt.Errorf("failed to delete router %q: %w", s.resource.ID, err)
+	}
+	return nil
+}
+
+// deletePortForwardings deletes the port forwardings of the floating IPs
+// routed by the router, which otherwise prevent clearing its gateway.
+func (s Router) deletePortForwardings(ctx context.Context) error {
+	return floatingips.List(s.client, floatingips.ListOpts{RouterID: s.resource.ID}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		fips, err := floatingips.ExtractFloatingIPs(page)
+		if err != nil {
+			return false, err
+		}
+		for _, fip := range fips {
+			if err := portforwarding.List(s.client, nil, fip.ID).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+				portForwardings, err := portforwarding.ExtractPortForwardings(page)
+				if err != nil {
+					return false, err
+				}
+				for _, portForwarding := range portForwardings {
+					if err := portforwarding.Delete(ctx, s.client, fip.ID, portForwarding.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+						return false, err
+					}
+				}
+				return true, nil
+			}); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return false, err
+			}
+		}
+		return true, nil
+	})
 }
 
 func (s Router) Type() string {
@@ -41,6 +97,10 @@ func (s Router) Name() string {
 	return s.resource.Name
 }
 
+func (s Router) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s Router) Tags() []string {
 	return s.resource.Tags
 }
@@ -54,13 +114,32 @@ func (s Router) ClusterID() string {
 	return ""
 }
 
+func (s Router) References() []string {
+	return append(append([]string{}, s.resource.networks...), s.resource.subnets...)
+}
+
+func (s Router) AttachedTo() []string {
+	return append(append([]string{}, s.resource.networks...), s.resource.subnets...)
+}
+
+// routerInterfaceOwners are the device owners of the ports connecting a
+// router to its subnets, depending on whether the router is legacy, HA or
+// distributed.
+var routerInterfaceOwners = map[string]bool{
+	"network:router_interface":               true,
+	"network:ha_router_replicated_interface": true,
+	"network:router_interface_distributed":   true,
+}
+
 type RouterParser struct {
 	routers.Router
-	CreatedAt time.Time `json:"created_at"`
-	subnets   []string
+	CreatedAt  time.Time `json:"created_at"`
+	interfaces []string
+	subnets    []string
+	networks   []string
 }
 
-func ListRouters(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func ListRouters(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
@@ -75,12 +154,17 @@ func ListRouters(ctx context.Context, client *gophercloud.ServiceClient) <-chan
 			}
 
 			for i := range routerPage.Routers {
-				if err := ports.List(client, ports.ListOpts{DeviceID: routerPage.Routers[i].ID, DeviceOwner: "network:router_interface"}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+				if err := ports.List(client, ports.ListOpts{DeviceID: routerPage.Routers[i].ID}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 					portList, err := ports.ExtractPorts(page)
 					if err != nil {
 						return false, err
 					}
 					for _, port := range portList {
+						if !routerInterfaceOwners[port.DeviceOwner] {
+							continue
+						}
+						routerPage.Routers[i].interfaces = append(routerPage.Routers[i].interfaces, port.ID)
+						routerPage.Routers[i].networks = append(routerPage.Routers[i].networks, port.NetworkID)
 						for j := range port.FixedIPs {
 							routerPage.Routers[i].subnets = append(routerPage.Routers[i].subnets, port.FixedIPs[j].SubnetID)
 						}
@@ -96,7 +180,7 @@ func ListRouters(ctx context.Context, client *gophercloud.ServiceClient) <-chan
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "routers", Err: err}
 		}
 	}()
 	return ch
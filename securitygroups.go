@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/rules"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
 	"github.com/gophercloud/gophercloud/v2/pagination"
 )
 
@@ -19,10 +24,85 @@ func (s SecurityGroup) CreatedAt() time.Time {
 	return s.resource.CreatedAt
 }
 
+// Delete first unwinds what keeps the group in use within its cluster: the
+// rules of the other stale groups of the cluster that allow traffic from this
+// one, and the stale ports of the cluster that still use it. Groups and
+// ports that do not belong to the same cluster are left untouched, as well
+// as those that are protected or would not be pruned.
 func (s SecurityGroup) Delete(ctx context.Context) error {
+	if clusterID := s.ClusterID(); clusterID != "" {
+		if err := s.deleteRemoteGroupRules(ctx, clusterID); err != nil {
+			return err
+		}
+		if err := s.detachFromPorts(ctx, clusterID); err != nil {
+			return err
+		}
+	}
 	return groups.Delete(ctx, s.client, s.resource.ID).ExtractErr()
 }
 
+// deleteRemoteGroupRules deletes the rules whose remote group is this one,
+// of the other security groups of the cluster that would be pruned too.
+func (s SecurityGroup) deleteRemoteGroupRules(ctx context.Context, clusterID string) error {
+	prunable := make(map[string]bool)
+	return rules.List(s.client, rules.ListOpts{RemoteGroupID: s.resource.ID}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		ruleList, err := rules.ExtractRules(page)
+		if err != nil {
+			return false, err
+		}
+		for _, rule := range ruleList {
+			if rule.SecGroupID == s.resource.ID {
+				continue
+			}
+			isPrunable, ok := prunable[rule.SecGroupID]
+			if !ok {
+				group, err := groups.Get(ctx, s.client, rule.SecGroupID).Extract()
+				if err != nil {
+					return false, fmt.Errorf("failed to get security group %q referencing %q: %w", rule.SecGroupID, s.resource.ID, err)
+				}
+				isPrunable = prunableAlong(SecurityGroup{resource: group}, clusterID)
+				prunable[rule.SecGroupID] = isPrunable
+			}
+			if !isPrunable {
+				continue
+			}
+			log.Printf("Deleting rule %q of security group %q referencing security group %q\n", rule.ID, rule.SecGroupID, s.resource.ID)
+			if err := rules.Delete(ctx, s.client, rule.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return false, fmt.Errorf("failed to delete rule %q of security group %q: %w", rule.ID, rule.SecGroupID, err)
+			}
+		}
+		return true, nil
+	})
+}
+
+// detachFromPorts removes the security group from the ports of the cluster
+// that use it and would be pruned too. Other ports keep it, so that deleting
+// the group fails while they use it.
+func (s SecurityGroup) detachFromPorts(ctx context.Context, clusterID string) error {
+	return ports.List(s.client, ports.ListOpts{SecurityGroups: []string{s.resource.ID}}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		portList, err := ports.ExtractPorts(page)
+		if err != nil {
+			return false, err
+		}
+		for i := range portList {
+			if !prunableAlong(Port{resource: &portList[i]}, clusterID) {
+				continue
+			}
+			securityGroups := make([]string, 0, len(portList[i].SecurityGroups))
+			for _, id := range portList[i].SecurityGroups {
+				if id != s.resource.ID {
+					securityGroups = append(securityGroups, id)
+				}
+			}
+			log.Printf("Removing security group %q from port %q\n", s.resource.ID, portList[i].ID)
+			if _, err := ports.Update(ctx, s.client, portList[i].ID, ports.UpdateOpts{SecurityGroups: &securityGroups}).Extract(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return false, fmt.Errorf("failed to remove security group %q from port %q: %w", s.resource.ID, portList[i].ID, err)
+			}
+		}
+		return true, nil
+	})
+}
+
 func (s SecurityGroup) Type() string {
 	return "security group"
 }
@@ -35,6 +115,10 @@ func (s SecurityGroup) Name() string {
 	return s.resource.Name
 }
 
+func (s SecurityGroup) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s SecurityGroup) Tags() []string {
 	return s.resource.Tags
 }
@@ -48,7 +132,7 @@ func (s SecurityGroup) ClusterID() string {
 	return ""
 }
 
-func ListSecurityGroups(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func ListSecurityGroups(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
@@ -64,7 +148,7 @@ func ListSecurityGroups(ctx context.Context, client *gophercloud.ServiceClient)
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "securitygroups", Err: err}
 		}
 	}()
 	return ch
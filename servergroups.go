@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+)
+
+// ServerGroup has neither a creation time nor a cluster ID of its own: both
+// are derived from its member servers, or from the network of its cluster
+// once it has no members left.
+type ServerGroup struct {
+	resource           *servergroups.ServerGroup
+	client             *gophercloud.ServiceClient
+	members            []Resource
+	clusterID          string
+	associatedResource Resource
+}
+
+// installerServerGroupName matches the names the installer gives to the
+// server groups of a cluster, e.g. "<infra ID>-master" or
+// "<infra ID>-worker-az1", capturing the infra ID.
+var installerServerGroupName = regexp.MustCompile(`^(.+-[a-z0-9]{5})-(master|worker)(-.+)?$`)
+
+// CreatedAt returns the creation time of the oldest member server, or of the
+// cluster network if the group has no members left. Leaked groups, which
+// have neither, are always stale.
+func (s ServerGroup) CreatedAt() time.Time {
+	var oldest time.Time
+	for _, member := range s.members {
+		if oldest.IsZero() || member.CreatedAt().Before(oldest) {
+			oldest = member.CreatedAt()
+		}
+	}
+	if oldest.IsZero() && s.associatedResource != nil {
+		return s.associatedResource.CreatedAt()
+	}
+	return oldest
+}
+
+func (s ServerGroup) Delete(ctx context.Context) error {
+	return servergroups.Delete(ctx, s.client, s.resource.ID).ExtractErr()
+}
+
+func (s ServerGroup) Type() string {
+	return "server group"
+}
+
+func (s ServerGroup) ID() string {
+	return s.resource.ID
+}
+
+func (s ServerGroup) Name() string {
+	return s.resource.Name
+}
+
+func (s ServerGroup) ProjectID() string {
+	return s.resource.ProjectID
+}
+
+func (s ServerGroup) ClusterID() string {
+	return s.clusterID
+}
+
+// DependsOn returns the member servers, so that the group is deleted after
+// them.
+func (s ServerGroup) DependsOn() []string {
+	ids := make([]string, len(s.members))
+	for i := range s.members {
+		ids[i] = s.members[i].ID()
+	}
+	return ids
+}
+
+// ListServerGroups lists the server groups, associating them with the given
+// servers and networks. Members that are not among the given servers are
+// fetched, and only discarded once Nova confirms they are gone.
+//
+// Empty groups that cannot be associated with a cluster network are leaks
+// of a torn-down cluster if their members are all gone, or if they are named
+// after an installer cluster: their cluster has neither servers nor networks
+// left, so they are listed as always stale, and reported as orphans with
+// --orphans. Other such groups are skipped, as nothing tells how old they
+// are.
+func ListServerGroups(ctx context.Context, client *gophercloud.ServiceClient, servers []Resource, networks <-chan Resource, errs chan<- error) <-chan Resource {
+	ch := make(chan Resource)
+	serversByID := make(map[string]Resource, len(servers))
+	for _, server := range servers {
+		serversByID[server.ID()] = server
+	}
+	clusterNetworks := make(map[string]Resource)
+	for network := range networks {
+		if clusterNetwork, ok := network.(Clusterer); ok && clusterNetwork.ClusterID() != "" {
+			clusterNetworks[clusterNetwork.ClusterID()] = network
+		}
+	}
+	go func() {
+		defer close(ch)
+		if err := servergroups.List(client, servergroups.ListOpts{AllProjects: allProjects}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+			groups, err := servergroups.ExtractServerGroups(page)
+			if err != nil {
+				return true, err
+			}
+			for i := range groups {
+				g := ServerGroup{
+					resource: &groups[i],
+					client:   client,
+				}
+				for _, memberID := range groups[i].Members {
+					member, err := serverGroupMember(ctx, client, serversByID, memberID)
+					if err != nil {
+						return true, err
+					}
+					if member != nil {
+						g.members = append(g.members, member)
+					}
+				}
+
+				// The installer names the server groups after the
+				// cluster, e.g. "<cluster ID>-master".
+				for _, member := range g.members {
+					if g.clusterID = clusterIDOf(member); g.clusterID != "" {
+						break
+					}
+				}
+				if g.clusterID == "" {
+					for clusterID := range clusterNetworks {
+						if strings.HasPrefix(g.resource.Name, clusterID+"-") {
+							g.clusterID = clusterID
+							break
+						}
+					}
+				}
+				if g.clusterID == "" {
+					if match := installerServerGroupName.FindStringSubmatch(g.resource.Name); match != nil {
+						g.clusterID = match[1]
+					}
+				}
+				if n, ok := clusterNetworks[g.clusterID]; ok {
+					g.associatedResource = n
+				}
+				if len(g.members) == 0 && g.associatedResource == nil && len(groups[i].Members) == 0 && !installerServerGroupName.MatchString(g.resource.Name) {
+					log.Printf("Skipping server group %q, which has neither members nor a cluster network\n", g.resource.ID)
+					continue
+				}
+				ch <- g
+			}
+			return true, nil
+		}); err != nil {
+			errs <- ListError{ResourceType: "servergroups", Err: err}
+		}
+	}()
+	return ch
+}
+
+// serverGroupMember returns the server of the given ID, or nil if it is gone.
+func serverGroupMember(ctx context.Context, client *gophercloud.ServiceClient, serversByID map[string]Resource, id string) (Resource, error) {
+	if server, ok := serversByID[id]; ok {
+		return server, nil
+	}
+	server, err := servers.Get(ctx, client, id).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &Server{
+		resource: server,
+		client:   client,
+	}, nil
+}
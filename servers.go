@@ -22,6 +22,14 @@ func (s Server) Delete(ctx context.Context) error {
 	return servers.Delete(ctx, s.client, s.resource.ID).ExtractErr()
 }
 
+func (s Server) Status(ctx context.Context) (string, error) {
+	server, err := servers.Get(ctx, s.client, s.resource.ID).Extract()
+	if err != nil {
+		return "", err
+	}
+	return server.Status, nil
+}
+
 func (s Server) Type() string {
 	return "server"
 }
@@ -34,6 +42,10 @@ func (s Server) Name() string {
 	return s.resource.Name
 }
 
+func (s Server) ProjectID() string {
+	return s.resource.TenantID
+}
+
 func (s Server) Tags() []string {
 	if s.resource.Tags != nil {
 		return *s.resource.Tags
@@ -45,11 +57,11 @@ func (s Server) ClusterID() string {
 	return s.resource.Metadata["openshiftClusterID"]
 }
 
-func ListServers(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func ListServers(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
-		if err := servers.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		if err := servers.List(client, servers.ListOpts{AllTenants: allProjects}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := servers.ExtractServers(page)
 			for i := range resources {
 				ch <- &Server{
@@ -59,7 +71,7 @@ func ListServers(ctx context.Context, client *gophercloud.ServiceClient) <-chan
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "servers", Err: err}
 		}
 	}()
 	return ch
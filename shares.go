@@ -26,6 +26,14 @@ func (s Share) Delete(ctx context.Context) error {
 	return shares.Delete(ctx, s.client, s.resource.ID).ExtractErr()
 }
 
+func (s Share) Status(ctx context.Context) (string, error) {
+	share, err := shares.Get(ctx, s.client, s.resource.ID).Extract()
+	if err != nil {
+		return "", err
+	}
+	return share.Status, nil
+}
+
 func (s Share) Type() string {
 	return "share"
 }
@@ -38,15 +46,23 @@ func (s Share) Name() string {
 	return s.resource.Name
 }
 
+func (s Share) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s Share) ClusterID() string {
 	return s.resource.Metadata["manila.csi.openstack.org/cluster"]
 }
 
-func ListShares(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s Share) Metadata() map[string]string {
+	return s.resource.Metadata
+}
+
+func ListShares(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
-		if err := shares.ListDetail(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		if err := shares.ListDetail(client, shares.ListOpts{AllTenants: allProjects}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := shares.ExtractShares(page)
 			for i := range resources {
 				ch <- Share{
@@ -56,7 +72,7 @@ func ListShares(ctx context.Context, client *gophercloud.ServiceClient) <-chan R
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "shares", Err: err}
 		}
 	}()
 	return ch
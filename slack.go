@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,9 +17,18 @@ func reportToSlack(slackHook string, report Report) error {
 	if clusterType := os.Getenv("CLUSTER_TYPE"); clusterType != "" {
 		message.WriteString(" for cluster " + clusterType)
 	}
+	if len(cloudNames) > 1 {
+		message.WriteString(" in clouds " + strings.Join(cloudNames, ", "))
+	}
 	message.WriteRune('\n')
 	for _, resource := range report.FailedToDelete {
-		message.WriteString(fmt.Sprintf("%s: %q\n", resource.Type(), resource.ID()))
+		message.WriteString(fmt.Sprintf("%s%s: %q\n", locationPrefix(resource), resource.Type(), resource.ID()))
+	}
+	for _, resource := range report.WentToError {
+		message.WriteString(fmt.Sprintf("%s%s: %q (went to error while deleting)\n", locationPrefix(resource), resource.Type(), resource.ID()))
+	}
+	for _, err := range cloudErrors(report) {
+		message.WriteString(fmt.Sprintf("[%s] cloud not pruned: %v\n", err.Cloud, err.Err))
 	}
 
 	var msg bytes.Buffer
@@ -50,3 +60,33 @@ func reportToSlack(slackHook string, report Report) error {
 
 	return nil
 }
+
+// locationPrefix returns the cloud and region of the resource, formatted to
+// precede its description, if they are known.
+func locationPrefix(resource Resource) string {
+	located, ok := resource.(locatedResource)
+	if !ok {
+		return ""
+	}
+	switch {
+	case located.Cloud == "":
+		return "[" + located.Region + "] "
+	case located.Region == "":
+		return "[" + located.Cloud + "] "
+	default:
+		return "[" + located.Cloud + "/" + located.Region + "] "
+	}
+}
+
+// cloudErrors returns the errors of the report about clouds that could not
+// be pruned at all.
+func cloudErrors(report Report) []CloudError {
+	var cloudErrs []CloudError
+	for _, err := range report.Errors {
+		var cloudErr CloudError
+		if errors.As(err, &cloudErr) {
+			cloudErrs = append(cloudErrs, cloudErr)
+		}
+	}
+	return cloudErrs
+}
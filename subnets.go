@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/routers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/subnets"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+)
+
+type Subnet struct {
+	resource *SubnetParser
+	client   *gophercloud.ServiceClient
+}
+
+func (s Subnet) CreatedAt() time.Time {
+	return s.resource.CreatedAt
+}
+
+// Delete removes the subnet from the routers it is attached to, and deletes
+// the unbound ports of its cluster left on it, before deleting the subnet.
+// DHCP ports are left to Neutron, ports bound to a device are left to the
+// deletion of their device, and the other ports must be pruned on their own.
+func (s Subnet) Delete(ctx context.Context) error {
+	if err := ports.List(s.client, ports.ListOpts{FixedIPs: []ports.FixedIPOpts{{SubnetID: s.resource.ID}}}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		portList, err := ports.ExtractPorts(page)
+		if err != nil {
+			return false, err
+		}
+		for _, port := range portList {
+			switch {
+			case routerInterfaceOwners[port.DeviceOwner]:
+				if _, err := routers.RemoveInterface(ctx, s.client, port.DeviceID, routers.RemoveInterfaceOpts{SubnetID: s.resource.ID}).Extract(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+					return false, fmt.Errorf("failed to remove subnet %q from router %q: %w", s.resource.ID, port.DeviceID, err)
+				}
+			case port.DeviceOwner == "network:dhcp", port.DeviceID != "":
+			case prunableAlong(Port{resource: &port}, s.ClusterID()):
+				if err := ports.Delete(ctx, s.client, port.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+					return false, fmt.Errorf("failed to delete port %q of subnet %q: %w", port.ID, s.resource.ID, err)
+				}
+			}
+		}
+		return true, nil
+	}); err != nil {
+		return err
+	}
+	return subnets.Delete(ctx, s.client, s.resource.ID).ExtractErr()
+}
+
+func (s Subnet) Type() string {
+	return "subnet"
+}
+
+func (s Subnet) ID() string {
+	return s.resource.ID
+}
+
+func (s Subnet) Name() string {
+	return s.resource.Name
+}
+
+func (s Subnet) ProjectID() string {
+	return s.resource.ProjectID
+}
+
+func (s Subnet) Tags() []string {
+	return s.resource.Tags
+}
+
+func (s Subnet) ClusterID() string {
+	for _, tag := range s.resource.Tags {
+		if value := strings.TrimPrefix(tag, "openshiftClusterID="); value != tag {
+			return value
+		}
+	}
+	return ""
+}
+
+func (s Subnet) References() []string {
+	return []string{s.resource.NetworkID}
+}
+
+// AttachedTo returns the network of the subnet, so that a protected network
+// protects its subnets.
+func (s Subnet) AttachedTo() []string {
+	return []string{s.resource.NetworkID}
+}
+
+// IsOwnedSubnet selects the subnets that belong to an OpenShift cluster, or
+// to the authenticated project. With --all-projects, the subnets of every
+// project are selected here, and filtered by project separately. Subnets of
+// the shared and provider networks of other projects are not meant to be
+// pruned.
+func IsOwnedSubnet(projectID string) func(Resource) bool {
+	return func(resource Resource) bool {
+		subnet, ok := resource.(Subnet)
+		if !ok {
+			return true
+		}
+		return subnet.ClusterID() != "" || allProjects || (projectID != "" && subnet.ProjectID() == projectID)
+	}
+}
+
+type SubnetParser struct {
+	subnets.Subnet
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ListSubnets(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
+	ch := make(chan Resource)
+	go func() {
+		defer close(ch)
+		if err := subnets.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+			var subnetPage struct {
+				Subnets []SubnetParser `json:"subnets"`
+			}
+			if err := (page.(subnets.SubnetPage)).ExtractInto(&subnetPage); err != nil {
+				return true, err
+			}
+			for i := range subnetPage.Subnets {
+				ch <- Subnet{
+					resource: &subnetPage.Subnets[i],
+					client:   client,
+				}
+			}
+			return true, nil
+		}); err != nil {
+			errs <- ListError{ResourceType: "subnets", Err: err}
+		}
+	}()
+	return ch
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/trunks"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
 	"github.com/gophercloud/gophercloud/v2/pagination"
 )
 
@@ -19,8 +23,49 @@ func (s Trunk) CreatedAt() time.Time {
 	return s.resource.CreatedAt
 }
 
+// Delete removes the subports of the trunk before deleting it, then deletes
+// its parent port and subport ports if they belong to the same cluster as
+// the trunk and would be pruned on their own.
 func (s Trunk) Delete(ctx context.Context) error {
-	return trunks.Delete(ctx, s.client, s.resource.ID).ExtractErr()
+	if len(s.resource.Subports) > 0 {
+		subports := make([]trunks.RemoveSubport, len(s.resource.Subports))
+		for i, subport := range s.resource.Subports {
+			subports[i] = trunks.RemoveSubport{PortID: subport.PortID}
+		}
+		if _, err := trunks.RemoveSubports(ctx, s.client, s.resource.ID, trunks.RemoveSubportsOpts{Subports: subports}).Extract(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to remove the subports of trunk %q: %w", s.resource.ID, err)
+		}
+	}
+
+	if err := trunks.Delete(ctx, s.client, s.resource.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete trunk %q: %w", s.resource.ID, err)
+	}
+
+	clusterID := s.ClusterID()
+	if clusterID == "" {
+		return nil
+	}
+	portIDs := []string{s.resource.PortID}
+	for _, subport := range s.resource.Subports {
+		portIDs = append(portIDs, subport.PortID)
+	}
+	for _, portID := range portIDs {
+		port, err := ports.Get(ctx, s.client, portID).Extract()
+		if err != nil {
+			if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to get port %q of trunk %q: %w", portID, s.resource.ID, err)
+		}
+		if !prunableAlong(Port{resource: port}, clusterID) {
+			continue
+		}
+		log.Printf("Deleting port %q of trunk %q\n", portID, s.resource.ID)
+		if err := ports.Delete(ctx, s.client, portID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to delete port %q of trunk %q: %w", portID, s.resource.ID, err)
+		}
+	}
+	return nil
 }
 
 func (s Trunk) Type() string {
@@ -35,6 +80,10 @@ func (s Trunk) Name() string {
 	return s.resource.Name
 }
 
+func (s Trunk) ProjectID() string {
+	return s.resource.ProjectID
+}
+
 func (s Trunk) Tags() []string {
 	return s.resource.Tags
 }
@@ -48,7 +97,21 @@ func (s Trunk) ClusterID() string {
 	return ""
 }
 
-func ListTrunks(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s Trunk) References() []string {
+	references := []string{s.resource.PortID}
+	for _, subport := range s.resource.Subports {
+		references = append(references, subport.PortID)
+	}
+	return references
+}
+
+// AttachedTo returns the parent port and the subports of the trunk, so that
+// protecting any of them protects the trunk.
+func (s Trunk) AttachedTo() []string {
+	return s.References()
+}
+
+func ListTrunks(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
@@ -62,7 +125,7 @@ func ListTrunks(ctx context.Context, client *gophercloud.ServiceClient) <-chan R
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "trunks", Err: err}
 		}
 	}()
 	return ch
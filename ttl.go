@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// resourceTypeNames maps the value returned by Typer.Type() to the name of
+// the resource type, as given to --include.
+var resourceTypeNames = map[string]string{
+	"application credential": "appcreds",
+	"container":              "containers",
+	"floating ip":            "floatingips",
+	"image":                  "images",
+	"key":                    "keypairs",
+	"load balancer":          "loadbalancers",
+	"network":                "networks",
+	"port":                   "ports",
+	"router":                 "routers",
+	"security group":         "securitygroups",
+	"server":                 "servers",
+	"server group":           "servergroups",
+	"share":                  "shares",
+	"subnet":                 "subnets",
+	"trunk":                  "trunks",
+	"volume":                 "volumes",
+	"volume snapshot":        "volumesnapshots",
+}
+
+var typeTTLs = func() map[string]time.Duration {
+	ttls := make(map[string]time.Duration)
+	for _, arg := range os.Args {
+		if value := strings.TrimPrefix(arg, "--type-ttl="); value != arg {
+			for _, ttl := range strings.Split(value, ",") {
+				resourceType, duration, ok := strings.Cut(ttl, "=")
+				if !ok {
+					panic(fmt.Sprintf("invalid type TTL %q: expected <type>=<ttl>", ttl))
+				}
+				if err := validateResourceTypes([]string{resourceType}, nil); err != nil {
+					panic(err)
+				}
+				d, err := time.ParseDuration(duration)
+				if err != nil {
+					panic(err)
+				}
+				ttls[resourceType] = d
+			}
+		}
+	}
+	return ttls
+}()
+
+// ttlOf returns the minimum age of the resources of the given type to be
+// pruned: from --type-ttl, else from the configuration file, else
+// --resource-ttl.
+func ttlOf(resourceType string) time.Duration {
+	if ttl, ok := typeTTLs[resourceType]; ok {
+		return ttl
+	}
+	if ttl := configuration.Resources[resourceType].TTL; ttl != 0 {
+		return ttl
+	}
+	return bestBefore
+}
+
+// OlderThanTTL selects the resources created before now minus the TTL of
+// their type.
+func OlderThanTTL(now time.Time) func(Resource) bool {
+	return func(resource Resource) bool {
+		return resource.CreatedAt().Before(now.Add(-ttlOf(resourceTypeNames[resource.Type()])))
+	}
+}
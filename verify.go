@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// verifyTimeouts holds, for each value returned by Typer.Type(), how long to
+// wait for a deleted resource to disappear.
+var verifyTimeouts = map[string]time.Duration{
+	"load balancer":   10 * time.Minute,
+	"server":          10 * time.Minute,
+	"share":           10 * time.Minute,
+	"volume":          5 * time.Minute,
+	"volume snapshot": 5 * time.Minute,
+}
+
+const verifyInterval = 5 * time.Second
+
+var errStillDeleting = errors.New("resource still exists after the verification timeout")
+
+type errWentToError struct{ status string }
+
+func (e errWentToError) Error() string {
+	return fmt.Sprintf("resource went to status %q while being deleted", e.status)
+}
+
+// statusOf returns the current status of the resource, or the empty string
+// if it does not implement Statuser or its status could not be fetched.
+func statusOf(ctx context.Context, r Resource) string {
+	statuser, ok := r.(Statuser)
+	if !ok {
+		return ""
+	}
+	status, err := statuser.Status(ctx)
+	if err != nil {
+		return ""
+	}
+	return status
+}
+
+// waitUntilGone polls the resource until it can no longer be found. It
+// returns errStillDeleting if the resource still exists after the timeout,
+// and errWentToError if it reaches an error status other than
+// statusBefore, its status before deletion: a server that was already in
+// ERROR keeps that status while it is being deleted. Resources that do not
+// implement Statuser are assumed to be gone as soon as Delete returns.
+func waitUntilGone(ctx context.Context, r Resource, statusBefore string, timeout time.Duration) error {
+	statuser, ok := r.(Statuser)
+	if !ok {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = verifyTimeouts[r.Type()]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		status, err := statuser.Status(ctx)
+		switch {
+		case gophercloud.ResponseCodeIs(err, http.StatusNotFound):
+			return nil
+		case err != nil:
+			if ctx.Err() != nil {
+				return errStillDeleting
+			}
+			log.Printf("error getting the status of %s %q: %v\n", r.Type(), r.ID(), err)
+		case strings.EqualFold(status, "deleted"):
+			return nil
+		case strings.Contains(strings.ToLower(status), "error") && !strings.EqualFold(status, statusBefore):
+			return errWentToError{status: status}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errStillDeleting
+		case <-time.After(verifyInterval):
+		}
+	}
+}
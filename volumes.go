@@ -2,34 +2,126 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/attachments"
 	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/volumeattach"
 	"github.com/gophercloud/gophercloud/v2/pagination"
 )
 
 type Volume struct {
-	resource *volumes.Volume
-	client   *gophercloud.ServiceClient
+	resource      *volumes.Volume
+	client        *gophercloud.ServiceClient
+	computeClient *gophercloud.ServiceClient
 }
 
 func (s Volume) CreatedAt() time.Time {
 	return s.resource.CreatedAt
 }
 
+// Detach removes the attachments of the volume. Attachments to existing
+// servers are removed through Nova, so that the server's block device
+// mapping stays consistent; only the attachments left by servers that are
+// gone are deleted from Cinder directly.
+func (s Volume) Detach(ctx context.Context) error {
+	for _, attachment := range s.resource.Attachments {
+		if err := s.detach(ctx, attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s Volume) Delete(ctx context.Context) error {
-	if s.resource.Attachments != nil {
-		s.client.Microversion = "3.44"
-		for _, attachment := range s.resource.Attachments {
-			err := attachments.Delete(ctx, s.client, attachment.AttachmentID).ExtractErr()
-			if err != nil {
-				return err
+	return volumes.Delete(ctx, s.client, s.resource.ID, volumes.DeleteOpts{Cascade: true}).ExtractErr()
+}
+
+func (s Volume) detach(ctx context.Context, attachment volumes.Attachment) error {
+	// Without Nova in the region, there is no server to detach from.
+	if s.computeClient == nil {
+		return s.deleteAttachment(ctx, attachment)
+	}
+	_, err := servers.Get(ctx, s.computeClient, attachment.ServerID).Extract()
+	switch {
+	case err == nil:
+		log.Printf("Detaching volume %q from server %q\n", s.resource.ID, attachment.ServerID)
+		if err := volumeattach.Delete(ctx, s.computeClient, attachment.ServerID, s.resource.ID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to detach volume %q from server %q: %w", s.resource.ID, attachment.ServerID, err)
+		}
+	case gophercloud.ResponseCodeIs(err, http.StatusNotFound):
+		return s.deleteAttachment(ctx, attachment)
+	default:
+		return fmt.Errorf("failed to get server %q attached to volume %q: %w", attachment.ServerID, s.resource.ID, err)
+	}
+	return nil
+}
+
+// deleteAttachment deletes from Cinder an attachment to a server that is
+// gone.
+func (s Volume) deleteAttachment(ctx context.Context, attachment volumes.Attachment) error {
+	// Attachments are managed by Cinder from microversion 3.44.
+	// Work on a copy, as the client is shared.
+	attachmentClient := *s.client
+	attachmentClient.Microversion = "3.44"
+	log.Printf("Deleting the attachment of volume %q to deleted server %q\n", s.resource.ID, attachment.ServerID)
+	if err := attachments.Delete(ctx, &attachmentClient, attachment.AttachmentID).ExtractErr(); err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete the attachment of volume %q to deleted server %q: %w", s.resource.ID, attachment.ServerID, err)
+	}
+	return nil
+}
+
+// WaitUntilDetached polls the volume until it has no attachments left. A
+// volume that was already in an error status before the detachment may stay
+// in it; any other error status fails the deletion.
+func (s Volume) WaitUntilDetached(ctx context.Context) error {
+	if len(s.resource.Attachments) == 0 {
+		return nil
+	}
+	timeout := verifyTimeout
+	if timeout == 0 {
+		timeout = verifyTimeouts[s.Type()]
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		volume, err := volumes.Get(ctx, s.client, s.resource.ID).Extract()
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return fmt.Errorf("volume %q was not detached after %s", s.resource.ID, timeout)
+			}
+			log.Printf("error getting the status of volume %q: %v\n", s.resource.ID, err)
+		case volume.Status == "available":
+			return nil
+		case strings.Contains(volume.Status, "error") && strings.EqualFold(volume.Status, s.resource.Status):
+			if len(volume.Attachments) == 0 {
+				return nil
 			}
+		case strings.Contains(volume.Status, "error"):
+			return fmt.Errorf("volume %q went to status %q while being detached", s.resource.ID, volume.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("volume %q was not detached after %s", s.resource.ID, timeout)
+		case <-time.After(verifyInterval):
 		}
 	}
-	return volumes.Delete(ctx, s.client, s.resource.ID, volumes.DeleteOpts{Cascade: true}).ExtractErr()
+}
+
+func (s Volume) Status(ctx context.Context) (string, error) {
+	volume, err := volumes.Get(ctx, s.client, s.resource.ID).Extract()
+	if err != nil {
+		return "", err
+	}
+	return volume.Status, nil
 }
 
 func (s Volume) Type() string {
@@ -44,25 +136,44 @@ func (s Volume) Name() string {
 	return s.resource.Name
 }
 
+func (s Volume) ProjectID() string {
+	return s.resource.TenantID
+}
+
 func (s Volume) ClusterID() string {
 	return s.resource.Metadata["cinder.csi.openstack.org/cluster"]
 }
 
-func ListVolumes(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s Volume) Metadata() map[string]string {
+	return s.resource.Metadata
+}
+
+func (s Volume) DependsOn() []string {
+	servers := make([]string, len(s.resource.Attachments))
+	for i := range s.resource.Attachments {
+		servers[i] = s.resource.Attachments[i].ServerID
+	}
+	return servers
+}
+
+// ListVolumes lists the volumes of the block storage client. The compute
+// client is used to detach them.
+func ListVolumes(ctx context.Context, client, computeClient *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
-		if err := volumes.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		if err := volumes.List(client, volumes.ListOpts{AllTenants: allProjects}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := volumes.ExtractVolumes(page)
 			for i := range resources {
 				ch <- &Volume{
-					resource: &resources[i],
-					client:   client,
+					resource:      &resources[i],
+					client:        client,
+					computeClient: computeClient,
 				}
 			}
 			return true, err
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "volumes", Err: err}
 		}
 	}()
 	return ch
@@ -10,8 +10,9 @@ import (
 )
 
 type Snapshot struct {
-	resource *snapshots.Snapshot
-	client   *gophercloud.ServiceClient
+	resource  *snapshots.Snapshot
+	projectID string
+	client    *gophercloud.ServiceClient
 }
 
 func (s Snapshot) CreatedAt() time.Time {
@@ -22,6 +23,14 @@ func (s Snapshot) Delete(ctx context.Context) error {
 	return snapshots.Delete(ctx, s.client, s.resource.ID).ExtractErr()
 }
 
+func (s Snapshot) Status(ctx context.Context) (string, error) {
+	snapshot, err := snapshots.Get(ctx, s.client, s.resource.ID).Extract()
+	if err != nil {
+		return "", err
+	}
+	return snapshot.Status, nil
+}
+
 func (s Snapshot) Type() string {
 	return "volume snapshot"
 }
@@ -34,21 +43,53 @@ func (s Snapshot) Name() string {
 	return s.resource.Name
 }
 
-func ListVolumeSnapshots(ctx context.Context, client *gophercloud.ServiceClient) <-chan Resource {
+func (s Snapshot) ProjectID() string {
+	return s.projectID
+}
+
+func (s Snapshot) Metadata() map[string]string {
+	return s.resource.Metadata
+}
+
+func (s Snapshot) References() []string {
+	return []string{s.resource.VolumeID}
+}
+
+func ListVolumeSnapshots(ctx context.Context, client *gophercloud.ServiceClient, errs chan<- error) <-chan Resource {
 	ch := make(chan Resource)
 	go func() {
 		defer close(ch)
-		if err := snapshots.List(client, nil).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		if err := snapshots.List(client, snapshots.ListOpts{AllTenants: allProjects}).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
 			resources, err := snapshots.ExtractSnapshots(page)
+			if err != nil {
+				return true, err
+			}
+
+			// The project is an extended attribute, which
+			// snapshots.Snapshot does not hold.
+			var projectPage struct {
+				Snapshots []struct {
+					ProjectID string `json:"os-extended-snapshot-attributes:project_id"`
+				} `json:"snapshots"`
+			}
+			if err := (page.(snapshots.SnapshotPage)).ExtractInto(&projectPage); err != nil {
+				return true, err
+			}
+
 			for i := range resources {
+				var projectID string
+				if i < len(projectPage.Snapshots) {
+					projectID = projectPage.Snapshots[i].ProjectID
+				}
 				ch <- &Snapshot{
-					resource: &resources[i],
-					client:   client,
+					resource:  &resources[i],
+					projectID: projectID,
+					client:    client,
 				}
 			}
-			return true, err
+			return true, nil
 		}); err != nil {
-			panic(err)
+			errs <- ListError{ResourceType: "volumesnapshots", Err: err}
 		}
 	}()
 	return ch